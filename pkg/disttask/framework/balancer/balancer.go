@@ -0,0 +1,66 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package balancer computes how a task's subtasks for its next step should
+// be spread across nodes, honouring the task's MaxNodeCount and TargetScope.
+package balancer
+
+import (
+	"sort"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+// Node is a live node the scheduler may assign subtasks to.
+type Node struct {
+	ID string
+	// Scope is the node's `--service-scope` tag. Empty means untagged.
+	Scope string
+}
+
+// NodeSet returns the nodes eligible to run subtasks for task, picked from
+// candidates (every live node whose scope matches task.TargetScope, or every
+// node if task.TargetScope is empty), capped to task.MaxNodeCount when set.
+// Nodes are chosen deterministically by sorting the eligible candidates, so
+// repeated calls with the same input produce the same set and subtasks land
+// on stable nodes across step boundaries.
+func NodeSet(task *proto.TaskBase, candidates []Node) []string {
+	nodes := make([]string, 0, len(candidates))
+	for _, node := range candidates {
+		if task.TargetScope == "" || node.Scope == task.TargetScope {
+			nodes = append(nodes, node.ID)
+		}
+	}
+	sort.Strings(nodes)
+	if task.MaxNodeCount > 0 && len(nodes) > task.MaxNodeCount {
+		nodes = nodes[:task.MaxNodeCount]
+	}
+	return nodes
+}
+
+// AssignSubtasks spreads count subtasks for a step as evenly as possible
+// across nodes, returning the chosen exec_id for each subtask in order. It
+// is used when a step's subtasks are created; subtasks already created for a
+// step are not reassigned when a ModifyMaxNodes or ModifyTargetScope
+// modification changes the node set, which only affects later steps.
+func AssignSubtasks(nodes []string, count int) []string {
+	if len(nodes) == 0 || count == 0 {
+		return nil
+	}
+	execIDs := make([]string, count)
+	for i := range execIDs {
+		execIDs[i] = nodes[i%len(nodes)]
+	}
+	return execIDs
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package handle offers the small, stable surface other components of TiDB
+// use to submit and observe DXF tasks, without depending on the scheduler or
+// storage packages directly.
+package handle
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+	"github.com/pingcap/tidb/pkg/disttask/framework/storage"
+)
+
+// taskMgr is set by the framework at bootstrap; tests set it via
+// testutil.NewTestDXFContext.
+var taskMgr *storage.TaskManager
+
+// SetTaskManager wires the TaskManager used by SubmitTask and friends.
+func SetTaskManager(mgr *storage.TaskManager) {
+	taskMgr = mgr
+}
+
+// SubmitOption customizes a task submitted via SubmitTask.
+type SubmitOption func(*proto.TaskBase)
+
+// WithPriority sets the task's initial scheduling priority. Tasks submitted
+// without this option get proto.DefaultTaskPriority.
+func WithPriority(priority proto.TaskPriority) SubmitOption {
+	return func(base *proto.TaskBase) {
+		base.Priority = int(priority)
+	}
+}
+
+// SubmitTask submits a new task of the given type and returns it once the
+// task row has been created. targetScope restricts scheduling to nodes
+// tagged with that scope; pass "" to allow any node.
+func SubmitTask(ctx context.Context, key string, taskType proto.TaskType, concurrency int, targetScope string, meta []byte, opts ...SubmitOption) (*proto.Task, error) {
+	task := &proto.Task{
+		TaskBase: proto.TaskBase{
+			Key:         key,
+			Type:        taskType,
+			State:       proto.TaskStatePending,
+			Step:        proto.StepInit,
+			Concurrency: concurrency,
+			TargetScope: targetScope,
+			Priority:    int(proto.DefaultTaskPriority),
+			CreateTime:  time.Now(),
+		},
+		Meta: meta,
+	}
+	for _, opt := range opts {
+		opt(&task.TaskBase)
+	}
+	id, err := taskMgr.CreateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	task.ID = id
+	if err := taskMgr.AppendTaskEvent(ctx, id, proto.EventSubmitted, "", ""); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
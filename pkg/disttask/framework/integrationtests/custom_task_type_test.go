@@ -0,0 +1,74 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/handle"
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+	"github.com/pingcap/tidb/pkg/disttask/framework/scheduler"
+	"github.com/pingcap/tidb/pkg/disttask/framework/taskexecutor"
+	"github.com/pingcap/tidb/pkg/disttask/framework/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const reindexTaskType proto.TaskType = "myorg.reindex"
+
+// reindexStepPlanner is a minimal StepPlanner for a fake, out-of-tree
+// "myorg.reindex" task type: a single step with one subtask that carries the
+// whole task Meta.
+type reindexStepPlanner struct{}
+
+func (reindexStepPlanner) Steps([]byte) ([]proto.Step, error) {
+	return []proto.Step{proto.StepOne}, nil
+}
+
+func (reindexStepPlanner) SubtaskMetas(taskMeta []byte, step proto.Step) ([][]byte, error) {
+	if step != proto.StepOne {
+		return nil, nil
+	}
+	return [][]byte{taskMeta}, nil
+}
+
+// TestCustomTaskType registers a fake "myorg.reindex" task type through the
+// public scheduler/taskexecutor registries, as an external Go module would,
+// and drives a task of that type end to end.
+func TestCustomTaskType(t *testing.T) {
+	c := testutil.NewTestDXFContext(t, 1, 16, true)
+	t.Cleanup(func() { scheduler.UnregisterTaskType(reindexTaskType) })
+
+	var ran atomic.Bool
+	scheduler.RegisterTaskType(scheduler.TaskTypeSpec{
+		Type:    reindexTaskType,
+		Planner: reindexStepPlanner{},
+	})
+	taskexecutor.RegisterTaskExecutor(taskexecutor.ExecutorSpec{
+		Type: reindexTaskType,
+		Runner: func(ctx context.Context, subtask *proto.Subtask) error {
+			ran.Store(true)
+			return nil
+		},
+	})
+
+	task, err := handle.SubmitTask(c.Ctx, "reindex-1", reindexTaskType, 2, "", []byte("my_index"))
+	require.NoError(t, err)
+
+	taskBase := testutil.WaitTaskDone(c.Ctx, t, task.Key)
+	require.Equal(t, proto.TaskStateSucceed, taskBase.State)
+	require.True(t, ran.Load())
+}
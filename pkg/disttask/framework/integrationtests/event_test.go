@@ -0,0 +1,167 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/handle"
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+	"github.com/pingcap/tidb/pkg/disttask/framework/testutil"
+	"github.com/pingcap/tidb/pkg/testkit/testfailpoint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskEventHistory(t *testing.T) {
+	c := testutil.NewTestDXFContext(t, 1, 16, true)
+	schedulerExt := testutil.GetMockSchedulerExt(c.MockCtrl, testutil.SchedulerInfo{
+		AllErrorRetryable: true,
+		StepInfos: []testutil.StepInfo{
+			{Step: proto.StepOne, SubtaskCnt: 1},
+			{Step: proto.StepTwo, SubtaskCnt: 1},
+		},
+	})
+	subtaskCh := make(chan struct{})
+	registerExampleTask(t, c.MockCtrl, schedulerExt, c.TestContext,
+		func(ctx context.Context, subtask *proto.Subtask) error {
+			select {
+			case <-subtaskCh:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		},
+	)
+
+	t.Run("modify pending task concurrency records submitted/modifying/modified", func(t *testing.T) {
+		var once sync.Once
+		modifySyncCh := make(chan struct{})
+		var theTask *proto.Task
+		testfailpoint.EnableCall(t, "github.com/pingcap/tidb/pkg/disttask/framework/scheduler/beforeGetSchedulableTasks", func() {
+			once.Do(func() {
+				task, err := handle.SubmitTask(c.Ctx, "ev1", proto.TaskTypeExample, 3, "", nil)
+				require.NoError(t, err)
+				require.NoError(t, c.TaskMgr.ModifyTaskByID(c.Ctx, task.ID, &proto.ModifyParam{
+					PrevState: proto.TaskStatePending,
+					Modifications: []proto.Modification{
+						{Type: proto.ModifyConcurrency, To: 7},
+					},
+				}))
+				theTask = task
+				<-modifySyncCh
+			})
+		})
+		modifySyncCh <- struct{}{}
+		subtaskCh <- struct{}{}
+		subtaskCh <- struct{}{}
+		taskBase := testutil.WaitTaskDone(c.Ctx, t, theTask.Key)
+		require.Equal(t, proto.TaskStateSucceed, taskBase.State)
+
+		events, err := c.TaskMgr.GetTaskEvents(c.Ctx, theTask.ID)
+		require.NoError(t, err)
+		kinds := make([]proto.EventKind, 0, len(events))
+		for _, ev := range events {
+			kinds = append(kinds, ev.Kind)
+		}
+		require.Equal(t, []proto.EventKind{
+			proto.EventSubmitted,
+			proto.EventModifying,
+			proto.EventModified,
+			proto.EventStepStarted,
+			proto.EventSubtaskAssigned,
+			proto.EventStepFinished,
+			proto.EventStepStarted,
+			proto.EventSubtaskAssigned,
+			proto.EventStepFinished,
+			proto.EventSucceeded,
+		}, kinds)
+	})
+
+	t.Run("modify paused task records paused/resumed", func(t *testing.T) {
+		var once sync.Once
+		syncCh := make(chan struct{})
+		var theTask *proto.Task
+		testfailpoint.EnableCall(t, "github.com/pingcap/tidb/pkg/disttask/framework/scheduler/beforeGetSchedulableTasks", func() {
+			once.Do(func() {
+				task, err := handle.SubmitTask(c.Ctx, "ev2", proto.TaskTypeExample, 3, "", nil)
+				require.NoError(t, err)
+				found, err := c.TaskMgr.PauseTask(c.Ctx, task.Key, proto.PausedReasonUser)
+				require.NoError(t, err)
+				require.True(t, found)
+				theTask = task
+				<-syncCh
+			})
+		})
+		syncCh <- struct{}{}
+		taskBase := testutil.WaitTaskDoneOrPaused(c.Ctx, t, theTask.Key)
+		require.Equal(t, proto.TaskStatePaused, taskBase.State)
+		found, err := c.TaskMgr.ResumeTask(c.Ctx, theTask.Key)
+		require.NoError(t, err)
+		require.True(t, found)
+		subtaskCh <- struct{}{}
+		subtaskCh <- struct{}{}
+		taskBase = testutil.WaitTaskDone(c.Ctx, t, theTask.Key)
+		require.Equal(t, proto.TaskStateSucceed, taskBase.State)
+
+		events, err := c.TaskMgr.GetTaskEvents(c.Ctx, theTask.ID)
+		require.NoError(t, err)
+		kinds := make([]proto.EventKind, 0, len(events))
+		for _, ev := range events {
+			kinds = append(kinds, ev.Kind)
+		}
+		require.Equal(t, []proto.EventKind{
+			proto.EventSubmitted,
+			proto.EventPaused,
+			proto.EventResumed,
+			proto.EventStepStarted,
+			proto.EventSubtaskAssigned,
+			proto.EventStepFinished,
+			proto.EventStepStarted,
+			proto.EventSubtaskAssigned,
+			proto.EventStepFinished,
+			proto.EventSucceeded,
+		}, kinds)
+	})
+
+	t.Run("subscribe observes modified event", func(t *testing.T) {
+		task, err := handle.SubmitTask(c.Ctx, "ev3", proto.TaskTypeExample, 3, "", nil)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(c.Ctx)
+		defer cancel()
+		sub := c.TaskMgr.SubscribeTaskEvents(ctx, proto.EventFilter{TaskID: task.ID})
+
+		require.NoError(t, c.TaskMgr.ModifyTaskByID(c.Ctx, task.ID, &proto.ModifyParam{
+			PrevState: proto.TaskStatePending,
+			Modifications: []proto.Modification{
+				{Type: proto.ModifyConcurrency, To: 9},
+			},
+		}))
+
+		var sawModified bool
+		for !sawModified {
+			select {
+			case ev := <-sub:
+				if ev.Kind == proto.EventModified {
+					sawModified = true
+				}
+			case <-ctx.Done():
+				t.Fatal("subscription closed before observing modified event")
+			}
+		}
+	})
+}
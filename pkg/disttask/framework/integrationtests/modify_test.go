@@ -132,7 +132,7 @@ func TestModifyTaskConcurrency(t *testing.T) {
 				task, err := handle.SubmitTask(c.Ctx, "k3", proto.TaskTypeExample, 3, "", nil)
 				require.NoError(t, err)
 				require.Equal(t, 3, task.Concurrency)
-				found, err := c.TaskMgr.PauseTask(c.Ctx, task.Key)
+				found, err := c.TaskMgr.PauseTask(c.Ctx, task.Key, proto.PausedReasonUser)
 				require.NoError(t, err)
 				require.True(t, found)
 				theTask = task
@@ -214,6 +214,188 @@ func TestModifyTaskConcurrency(t *testing.T) {
 	})
 }
 
+func TestModifyTaskMaxNodes(t *testing.T) {
+	c := testutil.NewTestDXFContext(t, 4, 16, true)
+	schedulerExt := testutil.GetMockSchedulerExt(c.MockCtrl, testutil.SchedulerInfo{
+		AllErrorRetryable: true,
+		StepInfos: []testutil.StepInfo{
+			{Step: proto.StepOne, SubtaskCnt: 4},
+			{Step: proto.StepTwo, SubtaskCnt: 4},
+		},
+	})
+	subtaskCh := make(chan struct{})
+	registerExampleTask(t, c.MockCtrl, schedulerExt, c.TestContext,
+		func(ctx context.Context, subtask *proto.Subtask) error {
+			select {
+			case <-subtaskCh:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		},
+	)
+
+	t.Run("modify pending task max nodes", func(t *testing.T) {
+		var once sync.Once
+		modifySyncCh := make(chan struct{})
+		var theTask *proto.Task
+		testfailpoint.EnableCall(t, "github.com/pingcap/tidb/pkg/disttask/framework/scheduler/beforeGetSchedulableTasks", func() {
+			once.Do(func() {
+				task, err := handle.SubmitTask(c.Ctx, "mn1", proto.TaskTypeExample, 3, "", nil)
+				require.NoError(t, err)
+				require.NoError(t, c.TaskMgr.ModifyTaskByID(c.Ctx, task.ID, &proto.ModifyParam{
+					PrevState: proto.TaskStatePending,
+					Modifications: []proto.Modification{
+						{Type: proto.ModifyMaxNodes, To: 2},
+					},
+				}))
+				theTask = task
+				gotTask, err := c.TaskMgr.GetTaskBaseByID(c.Ctx, theTask.ID)
+				require.NoError(t, err)
+				require.Equal(t, proto.TaskStateModifying, gotTask.State)
+				<-modifySyncCh
+			})
+		})
+		modifySyncCh <- struct{}{}
+		// finish subtasks
+		for range 8 {
+			subtaskCh <- struct{}{}
+		}
+		task2Base := testutil.WaitTaskDone(c.Ctx, t, theTask.Key)
+		require.Equal(t, proto.TaskStateSucceed, task2Base.State)
+		checkSubtaskNodeCount(t, c, theTask.ID, map[proto.Step]int{
+			proto.StepOne: 2,
+			proto.StepTwo: 2,
+		})
+	})
+
+	t.Run("modify running task max nodes at step two", func(t *testing.T) {
+		var once sync.Once
+		modifySyncCh := make(chan struct{})
+		testfailpoint.EnableCall(t, "github.com/pingcap/tidb/pkg/disttask/framework/scheduler/beforeRefreshTask", func(task *proto.Task) {
+			if task.State != proto.TaskStateRunning && task.Step != proto.StepTwo {
+				return
+			}
+			once.Do(func() {
+				require.NoError(t, c.TaskMgr.ModifyTaskByID(c.Ctx, task.ID, &proto.ModifyParam{
+					PrevState: proto.TaskStateRunning,
+					Modifications: []proto.Modification{
+						{Type: proto.ModifyMaxNodes, To: 1},
+					},
+				}))
+				<-modifySyncCh
+			})
+		})
+		task, err := handle.SubmitTask(c.Ctx, "mn2", proto.TaskTypeExample, 3, "", nil)
+		require.NoError(t, err)
+		// finish StepOne
+		for range 4 {
+			subtaskCh <- struct{}{}
+		}
+		modifySyncCh <- struct{}{}
+		require.Eventually(t, func() bool {
+			gotTask, err2 := c.TaskMgr.GetTaskByID(c.Ctx, task.ID)
+			require.NoError(t, err2)
+			return gotTask.State == proto.TaskStateRunning
+		}, 10*time.Second, 100*time.Millisecond)
+		// finish StepTwo
+		for range 4 {
+			subtaskCh <- struct{}{}
+		}
+		task2Base := testutil.WaitTaskDone(c.Ctx, t, task.Key)
+		require.Equal(t, proto.TaskStateSucceed, task2Base.State)
+		checkSubtaskNodeCount(t, c, task.ID, map[proto.Step]int{
+			proto.StepOne: 4,
+			proto.StepTwo: 1,
+		})
+	})
+}
+
+func TestModifyTaskTargetScope(t *testing.T) {
+	c := testutil.NewTestDXFContext(t, 4, 16, true)
+	schedulerExt := testutil.GetMockSchedulerExt(c.MockCtrl, testutil.SchedulerInfo{
+		AllErrorRetryable: true,
+		StepInfos: []testutil.StepInfo{
+			{Step: proto.StepOne, SubtaskCnt: 2},
+			{Step: proto.StepTwo, SubtaskCnt: 2},
+		},
+	})
+	subtaskCh := make(chan struct{})
+	registerExampleTask(t, c.MockCtrl, schedulerExt, c.TestContext,
+		func(ctx context.Context, subtask *proto.Subtask) error {
+			select {
+			case <-subtaskCh:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		},
+	)
+
+	t.Run("modify paused task target scope", func(t *testing.T) {
+		var once sync.Once
+		syncCh := make(chan struct{})
+		var theTask *proto.Task
+		testfailpoint.EnableCall(t, "github.com/pingcap/tidb/pkg/disttask/framework/scheduler/beforeGetSchedulableTasks", func() {
+			once.Do(func() {
+				task, err := handle.SubmitTask(c.Ctx, "ts1", proto.TaskTypeExample, 2, "scope-a", nil)
+				require.NoError(t, err)
+				found, err := c.TaskMgr.PauseTask(c.Ctx, task.Key, proto.PausedReasonUser)
+				require.NoError(t, err)
+				require.True(t, found)
+				theTask = task
+				<-syncCh
+			})
+		})
+		syncCh <- struct{}{}
+		taskBase := testutil.WaitTaskDoneOrPaused(c.Ctx, t, theTask.Key)
+		require.Equal(t, proto.TaskStatePaused, taskBase.State)
+		require.NoError(t, c.TaskMgr.ModifyTaskByID(c.Ctx, theTask.ID, &proto.ModifyParam{
+			PrevState: proto.TaskStatePaused,
+			Modifications: []proto.Modification{
+				{Type: proto.ModifyTargetScope, To: "scope-b"},
+			},
+		}))
+		taskBase = testutil.WaitTaskDoneOrPaused(c.Ctx, t, theTask.Key)
+		require.Equal(t, proto.TaskStatePaused, taskBase.State)
+		found, err := c.TaskMgr.ResumeTask(c.Ctx, theTask.Key)
+		require.NoError(t, err)
+		require.True(t, found)
+		for range 4 {
+			subtaskCh <- struct{}{}
+		}
+		task2Base := testutil.WaitTaskDone(c.Ctx, t, theTask.Key)
+		require.Equal(t, proto.TaskStateSucceed, task2Base.State)
+
+		// the task never ran before the scope moved from scope-a to
+		// scope-b, so every subtask of both steps should have landed on a
+		// scope-b node, never on a scope-a one.
+		checkSubtaskNodeScope(t, c, theTask.ID, proto.StepOne, "scope-b")
+		checkSubtaskNodeScope(t, c, theTask.ID, proto.StepTwo, "scope-b")
+	})
+}
+
+func checkSubtaskNodeScope(t *testing.T, c *testutil.TestDXFContext, taskID int64, step proto.Step, expectedScope string) {
+	subtasks, err := c.TaskMgr.GetSubtasksWithHistory(c.Ctx, taskID, step)
+	require.NoError(t, err)
+	require.NotEmpty(t, subtasks)
+	for _, st := range subtasks {
+		require.Equal(t, expectedScope, c.TestContext.NodeScopes[st.ExecID])
+	}
+}
+
+func checkSubtaskNodeCount(t *testing.T, c *testutil.TestDXFContext, taskID int64, expectedStepNodeCnt map[proto.Step]int) {
+	for step, nodeCnt := range expectedStepNodeCnt {
+		subtasks, err := c.TaskMgr.GetSubtasksWithHistory(c.Ctx, taskID, step)
+		require.NoError(t, err)
+		nodes := make(map[string]struct{}, len(subtasks))
+		for _, st := range subtasks {
+			nodes[st.ExecID] = struct{}{}
+		}
+		require.Len(t, nodes, nodeCnt)
+	}
+}
+
 func checkSubtaskConcurrency(t *testing.T, c *testutil.TestDXFContext, taskID int64, expectedStepCon map[proto.Step]int) {
 	for step, con := range expectedStepCon {
 		subtasks, err := c.TaskMgr.GetSubtasksWithHistory(c.Ctx, taskID, step)
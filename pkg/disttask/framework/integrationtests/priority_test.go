@@ -0,0 +1,137 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/handle"
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+	"github.com/pingcap/tidb/pkg/disttask/framework/testutil"
+	"github.com/pingcap/tidb/pkg/testkit/testfailpoint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModifyTaskPriority(t *testing.T) {
+	c := testutil.NewTestDXFContext(t, 1, 16, true)
+	schedulerExt := testutil.GetMockSchedulerExt(c.MockCtrl, testutil.SchedulerInfo{
+		AllErrorRetryable: true,
+		StepInfos: []testutil.StepInfo{
+			{Step: proto.StepOne, SubtaskCnt: 1},
+			{Step: proto.StepTwo, SubtaskCnt: 1},
+		},
+	})
+	subtaskCh := make(chan struct{})
+	registerExampleTask(t, c.MockCtrl, schedulerExt, c.TestContext,
+		func(ctx context.Context, subtask *proto.Subtask) error {
+			select {
+			case <-subtaskCh:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		},
+	)
+
+	t.Run("modify pending task priority", func(t *testing.T) {
+		var once sync.Once
+		modifySyncCh := make(chan struct{})
+		var theTask *proto.Task
+		testfailpoint.EnableCall(t, "github.com/pingcap/tidb/pkg/disttask/framework/scheduler/beforeGetSchedulableTasks", func() {
+			once.Do(func() {
+				task, err := handle.SubmitTask(c.Ctx, "p1", proto.TaskTypeExample, 3, "", nil,
+					handle.WithPriority(proto.TaskPriorityLow))
+				require.NoError(t, err)
+				require.Equal(t, int(proto.TaskPriorityLow), task.Priority)
+				require.NoError(t, c.TaskMgr.ModifyTaskByID(c.Ctx, task.ID, &proto.ModifyParam{
+					PrevState: proto.TaskStatePending,
+					Modifications: []proto.Modification{
+						{Type: proto.ModifyPriority, To: int(proto.TaskPriorityHigh)},
+					},
+				}))
+				theTask = task
+				gotTask, err := c.TaskMgr.GetTaskBaseByID(c.Ctx, theTask.ID)
+				require.NoError(t, err)
+				require.Equal(t, proto.TaskStateModifying, gotTask.State)
+				<-modifySyncCh
+			})
+		})
+		modifySyncCh <- struct{}{}
+		subtaskCh <- struct{}{}
+		subtaskCh <- struct{}{}
+		task2Base := testutil.WaitTaskDone(c.Ctx, t, theTask.Key)
+		require.Equal(t, proto.TaskStateSucceed, task2Base.State)
+
+		persisted, err := c.TaskMgr.GetTaskBaseByID(c.Ctx, theTask.ID)
+		require.NoError(t, err)
+		require.Equal(t, int(proto.TaskPriorityHigh), persisted.Priority)
+	})
+
+	t.Run("high priority task preempts lowest priority running task", func(t *testing.T) {
+		lowTask, err := handle.SubmitTask(c.Ctx, "p2", proto.TaskTypeExample, 16, "",
+			nil, handle.WithPriority(proto.TaskPriorityLow))
+		require.NoError(t, err)
+
+		var once sync.Once
+		pausingSyncCh := make(chan struct{})
+		testfailpoint.EnableCall(t, "github.com/pingcap/tidb/pkg/disttask/framework/scheduler/beforeRefreshTask", func(task *proto.Task) {
+			if task.ID != lowTask.ID || task.State != proto.TaskStatePausing {
+				return
+			}
+			once.Do(func() { close(pausingSyncCh) })
+		})
+
+		highTask, err := handle.SubmitTask(c.Ctx, "p3", proto.TaskTypeExample, 16, "",
+			nil, handle.WithPriority(proto.TaskPriorityCritical))
+		require.NoError(t, err)
+
+		select {
+		case <-pausingSyncCh:
+		case <-time.After(10 * time.Second):
+			t.Fatal("low priority task was never preempted")
+		}
+
+		// drain the low priority task's in-flight subtask so it finishes
+		// draining and can be resumed later.
+		subtaskCh <- struct{}{}
+
+		require.Eventually(t, func() bool {
+			gotTask, err2 := c.TaskMgr.GetTaskByID(c.Ctx, highTask.ID)
+			require.NoError(t, err2)
+			return gotTask.State == proto.TaskStateRunning
+		}, 10*time.Second, 100*time.Millisecond)
+
+		subtaskCh <- struct{}{}
+		highBase := testutil.WaitTaskDone(c.Ctx, t, highTask.Key)
+		require.Equal(t, proto.TaskStateSucceed, highBase.State)
+
+		// highTask finishing frees its slots; the scheduler should resume
+		// lowTask on its own since it was only ever preempted, not paused by
+		// the user.
+		require.Eventually(t, func() bool {
+			gotTask, err2 := c.TaskMgr.GetTaskBaseByID(c.Ctx, lowTask.ID)
+			require.NoError(t, err2)
+			return gotTask.State == proto.TaskStateRunning
+		}, 10*time.Second, 100*time.Millisecond)
+
+		subtaskCh <- struct{}{}
+		subtaskCh <- struct{}{}
+		lowBase := testutil.WaitTaskDone(c.Ctx, t, lowTask.Key)
+		require.Equal(t, proto.TaskStateSucceed, lowBase.State)
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+	"github.com/pingcap/tidb/pkg/disttask/framework/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTriggerFiresOnSchedule creates an `@every 1m` trigger, fast-forwards
+// the cluster's mock clock three ticks, and asserts a task was submitted for
+// each one with the expected key and concurrency.
+func TestTriggerFiresOnSchedule(t *testing.T) {
+	c := testutil.NewTestDXFContext(t, 1, 16, true)
+
+	triggerID, err := c.TaskMgr.CreateTrigger(c.Ctx, &proto.Trigger{
+		KeyPrefix:   "nightly-reindex",
+		TaskType:    proto.TaskTypeExample,
+		CronExpr:    "@every 1m",
+		Concurrency: 4,
+		Enabled:     true,
+		NextFireAt:  c.Clock.Now(),
+		// the submitted tasks are never finished here, so queue rather than
+		// skip a firing while the previous task is still running.
+		OnOverlap: proto.OverlapQueue,
+	})
+	require.NoError(t, err)
+
+	var gotKeys, wantKeys []string
+	for i := 0; i < 3; i++ {
+		c.Clock.Advance(time.Minute)
+		wantKeys = append(wantKeys, fmt.Sprintf("nightly-reindex-%d", c.Clock.Now().Unix()))
+		testutil.RunTriggerTick(t, c)
+
+		triggers, err := c.TaskMgr.ListTriggers(c.Ctx)
+		require.NoError(t, err)
+		require.Len(t, triggers, 1)
+		require.Equal(t, triggerID, triggers[0].ID)
+		require.NotZero(t, triggers[0].LastTaskID)
+
+		task, err := c.TaskMgr.GetTaskBaseByID(c.Ctx, triggers[0].LastTaskID)
+		require.NoError(t, err)
+		require.Equal(t, 4, task.Concurrency)
+		gotKeys = append(gotKeys, task.Key)
+	}
+
+	require.Equal(t, wantKeys, gotKeys)
+}
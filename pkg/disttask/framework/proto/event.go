@@ -0,0 +1,80 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proto
+
+import "time"
+
+// EventKind is the kind of a TaskEvent.
+type EventKind string
+
+// Event kinds recorded over a task's lifetime, roughly in the order a
+// successful task emits them.
+const (
+	EventSubmitted      EventKind = "submitted"
+	EventStepStarted    EventKind = "step_started"
+	EventStepFinished   EventKind = "step_finished"
+	EventSubtaskAssigned EventKind = "subtask_assigned"
+	EventSubtaskFailed  EventKind = "subtask_failed"
+	EventModifying      EventKind = "modifying"
+	EventModified       EventKind = "modified"
+	EventPaused         EventKind = "paused"
+	EventResumed        EventKind = "resumed"
+	EventCancelled      EventKind = "cancelled"
+	EventSucceeded      EventKind = "succeeded"
+	EventFailed         EventKind = "failed"
+)
+
+// TaskEvent is one append-only entry in a task's event log. Seq is
+// monotonically increasing per TaskID, so consumers can resume a
+// subscription from the last Seq they saw. For EventModified, OldValue and
+// NewValue hold one Modification's before/after value formatted as a string;
+// for EventModifying, NewValue holds the whole list of requested
+// modifications. Some other kinds carry a detail in NewValue (the step for
+// step events, the exec ID for EventSubtaskAssigned, the error for
+// EventSubtaskFailed).
+type TaskEvent struct {
+	TaskID   int64
+	Seq      int64
+	Kind     EventKind
+	OldValue string
+	NewValue string
+	Time     time.Time
+}
+
+// EventFilter narrows a TaskManager.SubscribeTaskEvents subscription. A zero
+// value matches every event.
+type EventFilter struct {
+	// TaskID restricts the subscription to one task; 0 matches all tasks.
+	TaskID int64
+	// Kinds restricts the subscription to the given event kinds; empty
+	// matches all kinds.
+	Kinds []EventKind
+}
+
+// Match reports whether ev satisfies f.
+func (f EventFilter) Match(ev TaskEvent) bool {
+	if f.TaskID != 0 && f.TaskID != ev.TaskID {
+		return false
+	}
+	if len(f.Kinds) == 0 {
+		return true
+	}
+	for _, k := range f.Kinds {
+		if k == ev.Kind {
+			return true
+		}
+	}
+	return false
+}
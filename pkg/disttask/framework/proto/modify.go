@@ -0,0 +1,91 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proto
+
+import "fmt"
+
+// ModifyType is the kind of a single field change requested against a
+// running or pending task.
+type ModifyType int
+
+const (
+	// ModifyConcurrency changes TaskBase.Concurrency.
+	ModifyConcurrency ModifyType = iota
+	// ModifyMaxNodes changes TaskBase.MaxNodeCount, shrinking or growing how
+	// many nodes the task's subtasks may spread across.
+	ModifyMaxNodes
+	// ModifyTargetScope changes TaskBase.TargetScope, moving the task to
+	// schedule against a different set of nodes.
+	ModifyTargetScope
+	// ModifyPriority changes TaskBase.Priority, re-ordering the task in the
+	// scheduler's pending queue.
+	ModifyPriority
+)
+
+// String implements fmt.Stringer, mainly for log messages.
+func (t ModifyType) String() string {
+	switch t {
+	case ModifyConcurrency:
+		return "concurrency"
+	case ModifyMaxNodes:
+		return "max-nodes"
+	case ModifyTargetScope:
+		return "target-scope"
+	case ModifyPriority:
+		return "priority"
+	default:
+		return "unknown"
+	}
+}
+
+// Modification is a single requested field change. To carries the new value;
+// its meaning depends on Type: for ModifyConcurrency/ModifyMaxNodes it is the
+// new value parsed as an int, for ModifyTargetScope it is the new scope
+// string.
+type Modification struct {
+	Type ModifyType
+	To   any
+}
+
+// ModifyParam is persisted on a task while it is in TaskStateModifying, so
+// that the owner node that started the modification, and any owner that
+// takes over, can finish applying it.
+type ModifyParam struct {
+	// PrevState is the state the task was in before the modification was
+	// requested; the task is restored to it once the modification completes.
+	PrevState     TaskState
+	Modifications []Modification
+}
+
+// Validate checks that every modification in p carries a value of the
+// expected type for its ModifyType, so bad input is rejected at submission
+// time rather than when the scheduler applies it.
+func (p *ModifyParam) Validate() error {
+	for _, m := range p.Modifications {
+		switch m.Type {
+		case ModifyConcurrency, ModifyMaxNodes, ModifyPriority:
+			if _, ok := m.To.(int); !ok {
+				return fmt.Errorf("modification %s expects an int value, got %T", m.Type, m.To)
+			}
+		case ModifyTargetScope:
+			if _, ok := m.To.(string); !ok {
+				return fmt.Errorf("modification %s expects a string value, got %T", m.Type, m.To)
+			}
+		default:
+			return fmt.Errorf("unknown modification type %d", m.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proto
+
+// TaskPriority is a coarse, user-facing priority label. It maps to the
+// numeric score stored on TaskBase.Priority, which is what the scheduler
+// actually orders by.
+type TaskPriority int
+
+// Priority levels available to `handle.SubmitTask` and `ModifyPriority`.
+// The numeric value is also the default score for that level; operators may
+// submit any score in between via TaskBase.Priority directly.
+const (
+	TaskPriorityLow      TaskPriority = 100
+	TaskPriorityNormal   TaskPriority = 500
+	TaskPriorityHigh     TaskPriority = 900
+	TaskPriorityCritical TaskPriority = 1000
+)
+
+// DefaultTaskPriority is used for tasks submitted without an explicit
+// priority.
+const DefaultTaskPriority = TaskPriorityNormal
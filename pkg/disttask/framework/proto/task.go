@@ -0,0 +1,130 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proto
+
+import (
+	"math"
+	"time"
+)
+
+// TaskType is the type of a task.
+type TaskType string
+
+// Task types used across the DXF integration tests.
+const (
+	TaskTypeExample TaskType = "Example"
+)
+
+// TaskState is the state of a task.
+type TaskState string
+
+// Task states. A task moves through these states as the scheduler drives it
+// from submission to a terminal state.
+const (
+	TaskStatePending    TaskState = "pending"
+	TaskStateRunning    TaskState = "running"
+	TaskStateReverting  TaskState = "reverting"
+	TaskStateSucceed    TaskState = "succeed"
+	TaskStateFailed     TaskState = "failed"
+	TaskStateModifying  TaskState = "modifying"
+	TaskStatePausing    TaskState = "pausing"
+	TaskStatePaused     TaskState = "paused"
+	TaskStateCancelling TaskState = "cancelling"
+	TaskStateCancelled  TaskState = "cancelled"
+)
+
+// Step is the step of a task. Steps are ordered and task-type specific; a
+// task moves from StepInit through the steps returned by its scheduler and
+// finally to StepDone.
+type Step int64
+
+// Well-known steps shared by the example task type used in tests.
+const (
+	StepInit Step = -1
+	StepOne  Step = 1
+	StepTwo  Step = 2
+	StepDone Step = math.MaxInt64
+)
+
+// TaskBase holds the fields of a task that are cheap to read in bulk, e.g.
+// when listing tasks for scheduling decisions. It is embedded by Task, which
+// additionally carries the (potentially large) task Meta.
+type TaskBase struct {
+	ID          int64
+	Key         string
+	Type        TaskType
+	State       TaskState
+	Step        Step
+	Priority    int
+	Concurrency int
+	// MaxNodeCount caps how many nodes the task's subtasks may be scheduled
+	// onto for the current/next step. Zero means "no cap".
+	MaxNodeCount int
+	// TargetScope restricts scheduling to nodes tagged with this value, see
+	// the `--service-scope` server config. Empty means any scope.
+	TargetScope string
+	CreateTime  time.Time
+	StartTime   time.Time
+	// ModifyParam holds the in-flight modification while the task is in
+	// TaskStateModifying; nil otherwise.
+	ModifyParam *ModifyParam
+	// PausedReason records why a TaskStatePaused/TaskStatePausing task was
+	// paused; empty for any other state.
+	PausedReason PausedReason
+}
+
+// PausedReason distinguishes why a task was paused, so a paused task can be
+// resumed automatically only when it is safe to do so.
+type PausedReason string
+
+const (
+	// PausedReasonUser marks a task paused by an explicit user request; it
+	// is resumed only by another explicit user request.
+	PausedReasonUser PausedReason = "user"
+	// PausedReasonPreempted marks a task the priority scheduler paused to
+	// free its slots for a higher-scoring task; the scheduler resumes it
+	// automatically once budget allows.
+	PausedReasonPreempted PausedReason = "preempted"
+)
+
+// IsDone returns whether the task has reached a terminal state.
+func (t *TaskBase) IsDone() bool {
+	switch t.State {
+	case TaskStateSucceed, TaskStateFailed, TaskStateCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// Task is the full representation of a task, including its Meta which is
+// opaque to the framework and owned by the task-type's Scheduler/Executor.
+type Task struct {
+	TaskBase
+	Meta []byte
+}
+
+// Subtask is one unit of work belonging to a task's step.
+type Subtask struct {
+	ID          int64
+	TaskID      int64
+	Step        Step
+	Type        TaskType
+	Concurrency int
+	// ExecID is the id of the node the subtask is (or was) scheduled on.
+	ExecID string
+	Meta   []byte
+	State  TaskState
+}
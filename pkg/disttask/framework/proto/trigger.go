@@ -0,0 +1,50 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proto
+
+import "time"
+
+// OverlapPolicy decides what happens when a trigger comes due while the task
+// it last submitted is still non-terminal.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip drops this firing; the trigger just advances to its next
+	// scheduled time.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue submits the new task anyway, letting it queue behind the
+	// still-running one.
+	OverlapQueue
+	// OverlapCancelAndReplace cancels the still-running task before
+	// submitting the new one.
+	OverlapCancelAndReplace
+)
+
+// Trigger periodically submits tasks of TaskType on a cron/interval
+// schedule, persisted in the `tidb_dxf_trigger` table.
+type Trigger struct {
+	ID          int64
+	KeyPrefix   string
+	TaskType    TaskType
+	CronExpr    string
+	Concurrency int
+	Meta        []byte
+	NextFireAt  time.Time
+	// LastTaskID is the ID of the most recently submitted task, 0 if none
+	// has fired yet.
+	LastTaskID int64
+	Enabled    bool
+	OnOverlap  OverlapPolicy
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule computes the next fire time for a trigger's cron expression. It
+// supports the standard 5-field `min hour dom month dow` form (each field a
+// literal number, comma list, `*/step`, or `*`) plus the `@every <duration>`
+// shorthand used for simple polling-style triggers.
+type schedule interface {
+	next(from time.Time) time.Time
+}
+
+// parseCronExpr parses expr into a schedule, as accepted by
+// TaskManager.CreateTrigger's cron_expr column.
+func parseCronExpr(expr string) (schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if rest, ok := strings.CutPrefix(expr, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every expression %q: %w", expr, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid @every expression %q: duration must be positive", expr)
+		}
+		return everySchedule{interval: d}, nil
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields or be \"@every <duration>\"", expr)
+	}
+	sched := cronSchedule{}
+	matchers := []*fieldMatcher{&sched.minute, &sched.hour, &sched.dom, &sched.month, &sched.dow}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	for i, field := range fields {
+		m, err := parseField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: field %d: %w", expr, i, err)
+		}
+		*matchers[i] = m
+	}
+	return sched, nil
+}
+
+// everySchedule implements the `@every <duration>` shorthand.
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) next(from time.Time) time.Time {
+	return from.Add(s.interval)
+}
+
+// fieldMatcher matches a single cron field, `*`/`*/N`/explicit value list.
+type fieldMatcher struct {
+	any    bool
+	step   int
+	values map[int]struct{}
+}
+
+func parseField(field string, lo, hi int) (fieldMatcher, error) {
+	if field == "*" {
+		return fieldMatcher{any: true}, nil
+	}
+	if rest, ok := strings.CutPrefix(field, "*/"); ok {
+		step, err := strconv.Atoi(rest)
+		if err != nil || step <= 0 {
+			return fieldMatcher{}, fmt.Errorf("invalid step %q", field)
+		}
+		return fieldMatcher{any: true, step: step}, nil
+	}
+	values := make(map[int]struct{})
+	for _, part := range strings.Split(field, ",") {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < lo || v > hi {
+			return fieldMatcher{}, fmt.Errorf("invalid value %q, want %d-%d", part, lo, hi)
+		}
+		values[v] = struct{}{}
+	}
+	return fieldMatcher{values: values}, nil
+}
+
+func (m fieldMatcher) match(v int) bool {
+	if m.any {
+		if m.step == 0 {
+			return true
+		}
+		return v%m.step == 0
+	}
+	_, ok := m.values[v]
+	return ok
+}
+
+// cronSchedule is the standard 5-field cron expression.
+type cronSchedule struct {
+	minute, hour, dom, month, dow fieldMatcher
+}
+
+// next scans forward minute by minute for up to two years looking for a
+// match; cron expressions fire at most once a minute so this is cheap
+// enough and avoids the combinatorics of computing it in closed form.
+func (s cronSchedule) next(from time.Time) time.Time {
+	t := from.Truncate(time.Minute).Add(time.Minute)
+	limit := from.AddDate(2, 0, 0)
+	for t.Before(limit) {
+		if s.minute.match(t.Minute()) && s.hour.match(t.Hour()) &&
+			s.dom.match(t.Day()) && s.month.match(int(t.Month())) &&
+			s.dow.match(int(t.Weekday())) {
+			return t
+		}
+		t = t.Add(time.Minute)
+	}
+	return limit
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+const (
+	// agingFactor is added to a pending task's effective priority score for
+	// every second it has spent waiting, so an old Low-priority task
+	// eventually outranks a freshly submitted High-priority one.
+	agingFactor = 0.5
+	// maxAgingBoost caps how much aging alone can add to a task's score, so
+	// a single ancient task cannot starve everything else indefinitely.
+	maxAgingBoost = float64(proto.TaskPriorityCritical)
+)
+
+// effectiveScore returns task's scheduling score at "now": its stored
+// priority plus an aging boost proportional to how long it has been
+// pending, capped at maxAgingBoost.
+func effectiveScore(task *proto.TaskBase, now time.Time) float64 {
+	boost := float64(now.Sub(task.CreateTime).Seconds()) * agingFactor
+	if boost > maxAgingBoost {
+		boost = maxAgingBoost
+	}
+	return float64(task.Priority) + boost
+}
+
+// selectSchedulable orders pending by effective score (highest first,
+// stable on ties so equal-priority tasks keep FIFO order) and walks it in
+// that order, returning every task that still fits in what is left of
+// concurrencyBudget, assuming each task reserves task.Concurrency slots. A
+// task that does not fit is skipped; later tasks may still be picked.
+//
+// When a pending task does not fit and it outranks the lowest-scoring
+// running task not yet chosen as a victim, that running task is returned in
+// toPreempt so the caller can pause it and free its slots, and the pending
+// task is returned in toSchedule.
+func selectSchedulable(pending, running []*proto.TaskBase, concurrencyBudget int, now time.Time) (toSchedule, toPreempt []*proto.TaskBase) {
+	sort.SliceStable(pending, func(i, j int) bool {
+		return effectiveScore(pending[i], now) > effectiveScore(pending[j], now)
+	})
+
+	used := 0
+	for _, task := range running {
+		used += task.Concurrency
+	}
+
+	runningByScoreAsc := append([]*proto.TaskBase(nil), running...)
+	sort.SliceStable(runningByScoreAsc, func(i, j int) bool {
+		return effectiveScore(runningByScoreAsc[i], now) < effectiveScore(runningByScoreAsc[j], now)
+	})
+
+	for _, task := range pending {
+		if used+task.Concurrency <= concurrencyBudget {
+			toSchedule = append(toSchedule, task)
+			used += task.Concurrency
+			continue
+		}
+		if len(runningByScoreAsc) > 0 && effectiveScore(task, now) > effectiveScore(runningByScoreAsc[0], now) {
+			victim := runningByScoreAsc[0]
+			runningByScoreAsc = runningByScoreAsc[1:]
+			toPreempt = append(toPreempt, victim)
+			toSchedule = append(toSchedule, task)
+			used += task.Concurrency - victim.Concurrency
+		}
+	}
+	return toSchedule, toPreempt
+}
+
+// preempt pauses victim at the next step boundary, freeing its slots. Unlike
+// a user-initiated pause, it is marked PausedReasonPreempted so
+// resumePreemptedTasks resumes it automatically once the scheduler next has
+// budget for it.
+func (m *SchedulerManager) preempt(ctx context.Context, victim *proto.TaskBase) error {
+	_, err := m.taskMgr.PauseTask(ctx, victim.Key, proto.PausedReasonPreempted)
+	return err
+}
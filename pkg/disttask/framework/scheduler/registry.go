@@ -0,0 +1,78 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+// StepPlanner lays out a task type's step DAG: given a task's Meta, it
+// returns the ordered steps the task goes through and, for a given step,
+// the per-subtask meta to schedule.
+type StepPlanner interface {
+	// Steps returns the ordered steps a task with the given Meta runs
+	// through, not including proto.StepInit/proto.StepDone.
+	Steps(taskMeta []byte) ([]proto.Step, error)
+	// SubtaskMetas returns one []byte per subtask to create for step.
+	SubtaskMetas(taskMeta []byte, step proto.Step) ([][]byte, error)
+}
+
+// OnModifyFunc lets a task type react to a ModifyParam beyond the built-in
+// handling of ModifyConcurrency/ModifyMaxNodes/ModifyTargetScope/
+// ModifyPriority, e.g. to resize an in-memory structure embedded in Meta.
+type OnModifyFunc func(ctx context.Context, task *proto.Task, modifications []proto.Modification) error
+
+// TaskTypeSpec is what an external Go module supplies to teach the DXF
+// framework about a new task type.
+type TaskTypeSpec struct {
+	Type     proto.TaskType
+	Planner  StepPlanner
+	OnModify OnModifyFunc
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[proto.TaskType]TaskTypeSpec{}
+)
+
+// RegisterTaskType registers spec's StepPlanner (and optional OnModify hook)
+// for spec.Type. It is idempotent: registering the same type again just
+// replaces the previous spec, so an external module's init() can run more
+// than once (e.g. across test cases) without error. Safe for concurrent use.
+func RegisterTaskType(spec TaskTypeSpec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[spec.Type] = spec
+}
+
+// GetTaskTypeSpec returns the spec registered for taskType, if any.
+func GetTaskTypeSpec(taskType proto.TaskType) (TaskTypeSpec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	spec, ok := registry[taskType]
+	return spec, ok
+}
+
+// UnregisterTaskType removes a previously registered task type. Tests use it
+// in t.Cleanup so a fake task type registered for one test doesn't leak into
+// the next.
+func UnregisterTaskType(taskType proto.TaskType) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, taskType)
+}
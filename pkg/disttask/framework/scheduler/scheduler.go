@@ -0,0 +1,371 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scheduler drives tasks registered with the DXF from submission
+// through to a terminal state: it schedules subtasks for the current step,
+// watches for step completion, and reacts to pause/resume/modify requests.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pingcap/failpoint"
+	"github.com/pingcap/tidb/pkg/disttask/framework/balancer"
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+	"github.com/pingcap/tidb/pkg/disttask/framework/storage"
+)
+
+// TaskManager is the subset of storage.TaskManager the scheduler depends on.
+type TaskManager interface {
+	GetTaskBaseByID(ctx context.Context, taskID int64) (*proto.TaskBase, error)
+	GetTaskByID(ctx context.Context, taskID int64) (*proto.Task, error)
+	ListTasksByState(ctx context.Context, state proto.TaskState) ([]*proto.TaskBase, error)
+	UpdateTaskState(ctx context.Context, taskID int64, state proto.TaskState, step proto.Step) error
+	ModifiedTask(ctx context.Context, task *proto.Task) error
+	PauseTask(ctx context.Context, key string, reason proto.PausedReason) (found bool, err error)
+	ResumeTask(ctx context.Context, key string) (found bool, err error)
+	AppendTaskEvent(ctx context.Context, taskID int64, kind proto.EventKind, oldValue, newValue string) error
+	CreateSubtasks(ctx context.Context, subtasks []*proto.Subtask) error
+	GetSubtasks(ctx context.Context, taskID int64, step proto.Step) ([]*proto.Subtask, error)
+}
+
+// SchedulerManager runs this node's scheduling loop: it starts pending tasks
+// and drives running ones through their steps.
+type SchedulerManager struct {
+	taskMgr TaskManager
+	// liveNodes is the current set of nodes this SchedulerManager may assign
+	// subtasks to; balancer.NodeSet narrows it per task by scope and
+	// MaxNodeCount.
+	liveNodes []balancer.Node
+	// concurrencyBudget is the total subtask concurrency this node's
+	// scheduling loop may have in flight across every running task at once.
+	concurrencyBudget int
+}
+
+// NewSchedulerManager creates a SchedulerManager backed by the given
+// TaskManager, scheduling across liveNodes within concurrencyBudget total
+// subtask slots.
+func NewSchedulerManager(taskMgr *storage.TaskManager, liveNodes []balancer.Node, concurrencyBudget int) *SchedulerManager {
+	return &SchedulerManager{taskMgr: taskMgr, liveNodes: liveNodes, concurrencyBudget: concurrencyBudget}
+}
+
+// ScheduleOnce runs one scheduling tick: it starts newly schedulable tasks
+// (preempting lower-priority running tasks if the budget requires it),
+// refreshes every running/modifying task, and advances any task whose
+// current step has finished to its next step, to Paused, or to a terminal
+// state. It is meant to be called repeatedly, e.g. from a ticker loop.
+func (m *SchedulerManager) ScheduleOnce(ctx context.Context) error {
+	if err := m.resumePreemptedTasks(ctx); err != nil {
+		return err
+	}
+
+	schedulable, err := m.getSchedulableTasks(ctx)
+	if err != nil {
+		return err
+	}
+	for _, task := range schedulable {
+		if task.State == proto.TaskStatePending {
+			if err := m.startTask(ctx, task); err != nil {
+				return err
+			}
+		}
+	}
+
+	running, err := m.taskMgr.ListTasksByState(ctx, proto.TaskStateRunning)
+	if err != nil {
+		return err
+	}
+	pausing, err := m.taskMgr.ListTasksByState(ctx, proto.TaskStatePausing)
+	if err != nil {
+		return err
+	}
+	modifying, err := m.taskMgr.ListTasksByState(ctx, proto.TaskStateModifying)
+	if err != nil {
+		return err
+	}
+	for _, base := range append(append(running, pausing...), modifying...) {
+		task, err := m.refreshTask(ctx, base.ID)
+		if err != nil {
+			return err
+		}
+		if task.State == proto.TaskStateRunning || task.State == proto.TaskStatePausing {
+			if err := m.advanceTask(ctx, task); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getSchedulableTasks returns the pending tasks the priority/aging scheduler
+// decided to start this tick. Any running task it decided to preempt to make
+// room for one of them is paused here and is not part of the result.
+func (m *SchedulerManager) getSchedulableTasks(ctx context.Context) ([]*proto.TaskBase, error) {
+	failpoint.InjectCall("beforeGetSchedulableTasks")
+
+	pending, err := m.taskMgr.ListTasksByState(ctx, proto.TaskStatePending)
+	if err != nil {
+		return nil, err
+	}
+	running, err := m.taskMgr.ListTasksByState(ctx, proto.TaskStateRunning)
+	if err != nil {
+		return nil, err
+	}
+
+	toSchedule, toPreempt := selectSchedulable(pending, running, m.concurrencyBudget, time.Now())
+	for _, victim := range toPreempt {
+		if err := m.preempt(ctx, victim); err != nil {
+			return nil, err
+		}
+	}
+	return toSchedule, nil
+}
+
+// resumePreemptedTasks resumes, highest-score first, every TaskStatePaused
+// task the scheduler itself paused (PausedReasonPreempted) that now fits
+// within the concurrency budget alongside the tasks already running. Tasks a
+// user paused directly are left alone; only ResumeTask called on their
+// behalf resumes those.
+func (m *SchedulerManager) resumePreemptedTasks(ctx context.Context) error {
+	paused, err := m.taskMgr.ListTasksByState(ctx, proto.TaskStatePaused)
+	if err != nil {
+		return err
+	}
+	running, err := m.taskMgr.ListTasksByState(ctx, proto.TaskStateRunning)
+	if err != nil {
+		return err
+	}
+
+	used := 0
+	for _, task := range running {
+		used += task.Concurrency
+	}
+
+	var preempted []*proto.TaskBase
+	for _, task := range paused {
+		if task.PausedReason == proto.PausedReasonPreempted {
+			preempted = append(preempted, task)
+		}
+	}
+	now := time.Now()
+	sort.SliceStable(preempted, func(i, j int) bool {
+		return effectiveScore(preempted[i], now) > effectiveScore(preempted[j], now)
+	})
+
+	for _, task := range preempted {
+		if used+task.Concurrency > m.concurrencyBudget {
+			continue
+		}
+		if _, err := m.taskMgr.ResumeTask(ctx, task.Key); err != nil {
+			return err
+		}
+		used += task.Concurrency
+	}
+	return nil
+}
+
+// startTask creates the subtasks for task's first step, assigns them across
+// this SchedulerManager's live nodes via the balancer, and transitions the
+// task to TaskStateRunning.
+func (m *SchedulerManager) startTask(ctx context.Context, base *proto.TaskBase) error {
+	spec, ok := GetTaskTypeSpec(base.Type)
+	if !ok {
+		return fmt.Errorf("scheduler: no TaskTypeSpec registered for task type %q", base.Type)
+	}
+	task, err := m.taskMgr.GetTaskByID(ctx, base.ID)
+	if err != nil {
+		return err
+	}
+	steps, err := spec.Planner.Steps(task.Meta)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		if err := m.taskMgr.UpdateTaskState(ctx, task.ID, proto.TaskStateSucceed, proto.StepDone); err != nil {
+			return err
+		}
+		return m.taskMgr.AppendTaskEvent(ctx, task.ID, proto.EventSucceeded, "", "")
+	}
+	if err := m.taskMgr.AppendTaskEvent(ctx, task.ID, proto.EventStepStarted, "", fmt.Sprintf("%v", steps[0])); err != nil {
+		return err
+	}
+	if err := m.createStepSubtasks(ctx, &task.TaskBase, task.Meta, spec.Planner, steps[0]); err != nil {
+		return err
+	}
+	return m.taskMgr.UpdateTaskState(ctx, task.ID, proto.TaskStateRunning, steps[0])
+}
+
+// createStepSubtasks plans and persists the subtasks for task's given step,
+// spreading them across the node set the balancer picks for this task.
+func (m *SchedulerManager) createStepSubtasks(ctx context.Context, task *proto.TaskBase, taskMeta []byte, planner StepPlanner, step proto.Step) error {
+	metas, err := planner.SubtaskMetas(taskMeta, step)
+	if err != nil {
+		return err
+	}
+	nodes := balancer.NodeSet(task, m.liveNodes)
+	execIDs := balancer.AssignSubtasks(nodes, len(metas))
+	subtasks := make([]*proto.Subtask, 0, len(metas))
+	for i, meta := range metas {
+		subtasks = append(subtasks, &proto.Subtask{
+			TaskID: task.ID, Step: step, Type: task.Type, Concurrency: task.Concurrency,
+			ExecID: execIDs[i], Meta: meta, State: proto.TaskStatePending,
+		})
+	}
+	if err := m.taskMgr.CreateSubtasks(ctx, subtasks); err != nil {
+		return err
+	}
+	for _, subtask := range subtasks {
+		if err := m.taskMgr.AppendTaskEvent(ctx, task.ID, proto.EventSubtaskAssigned, "", subtask.ExecID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// advanceTask checks whether every subtask of task's current step has
+// finished and, if so, moves the task to its next step, to Paused (if it was
+// draining a pause request), or to a terminal state once its last step
+// completes. A task still at proto.StepInit (paused before its first step)
+// is moved to Paused, or, once resumed, started at its first step.
+func (m *SchedulerManager) advanceTask(ctx context.Context, task *proto.Task) error {
+	if task.Step == proto.StepInit {
+		if task.State == proto.TaskStatePausing {
+			return m.taskMgr.UpdateTaskState(ctx, task.ID, proto.TaskStatePaused, task.Step)
+		}
+		return m.startTask(ctx, &task.TaskBase)
+	}
+	subtasks, err := m.taskMgr.GetSubtasks(ctx, task.ID, task.Step)
+	if err != nil {
+		return err
+	}
+	for _, subtask := range subtasks {
+		if subtask.State == proto.TaskStatePending || subtask.State == proto.TaskStateRunning {
+			return nil // current step still in flight
+		}
+	}
+	if err := m.taskMgr.AppendTaskEvent(ctx, task.ID, proto.EventStepFinished, "", fmt.Sprintf("%v", task.Step)); err != nil {
+		return err
+	}
+
+	if task.State == proto.TaskStatePausing {
+		return m.taskMgr.UpdateTaskState(ctx, task.ID, proto.TaskStatePaused, task.Step)
+	}
+
+	for _, subtask := range subtasks {
+		if subtask.State == proto.TaskStateFailed {
+			if err := m.taskMgr.UpdateTaskState(ctx, task.ID, proto.TaskStateFailed, task.Step); err != nil {
+				return err
+			}
+			return m.taskMgr.AppendTaskEvent(ctx, task.ID, proto.EventFailed, "", "")
+		}
+	}
+
+	spec, ok := GetTaskTypeSpec(task.Type)
+	if !ok {
+		return fmt.Errorf("scheduler: no TaskTypeSpec registered for task type %q", task.Type)
+	}
+	steps, err := spec.Planner.Steps(task.Meta)
+	if err != nil {
+		return err
+	}
+	next := proto.StepDone
+	for i, step := range steps {
+		if step == task.Step && i+1 < len(steps) {
+			next = steps[i+1]
+			break
+		}
+	}
+	if next == proto.StepDone {
+		if err := m.taskMgr.UpdateTaskState(ctx, task.ID, proto.TaskStateSucceed, proto.StepDone); err != nil {
+			return err
+		}
+		return m.taskMgr.AppendTaskEvent(ctx, task.ID, proto.EventSucceeded, "", "")
+	}
+	if err := m.taskMgr.AppendTaskEvent(ctx, task.ID, proto.EventStepStarted, "", fmt.Sprintf("%v", next)); err != nil {
+		return err
+	}
+	if err := m.createStepSubtasks(ctx, &task.TaskBase, task.Meta, spec.Planner, next); err != nil {
+		return err
+	}
+	return m.taskMgr.UpdateTaskState(ctx, task.ID, proto.TaskStateRunning, next)
+}
+
+// refreshTask reloads task from storage and, if it is in TaskStateModifying,
+// applies every pending Modification before returning it to its previous
+// state. It is called once per scheduling tick for every task this node
+// owns.
+func (m *SchedulerManager) refreshTask(ctx context.Context, taskID int64) (*proto.Task, error) {
+	task, err := m.taskMgr.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	failpoint.InjectCall("beforeRefreshTask", task)
+
+	if task.State == proto.TaskStateModifying {
+		if err := m.applyModifications(ctx, task); err != nil {
+			return nil, err
+		}
+	}
+
+	failpoint.InjectCall("afterRefreshTask", task)
+	return task, nil
+}
+
+// applyModifications reads the task's persisted ModifyParam, applies each
+// Modification to the in-memory task, persists the result via ModifiedTask
+// and restores the task's previous state.
+func (m *SchedulerManager) applyModifications(ctx context.Context, task *proto.Task) error {
+	param := task.ModifyParam
+	if param == nil {
+		// another owner already finished the modification; nothing to do.
+		return nil
+	}
+	if err := m.taskMgr.AppendTaskEvent(ctx, task.ID, proto.EventModifying,
+		"", fmt.Sprintf("%+v", param.Modifications)); err != nil {
+		return err
+	}
+
+	for _, modification := range param.Modifications {
+		var oldValue string
+		switch modification.Type {
+		case proto.ModifyConcurrency:
+			oldValue = fmt.Sprintf("%d", task.Concurrency)
+			task.Concurrency = modification.To.(int)
+		case proto.ModifyMaxNodes:
+			oldValue = fmt.Sprintf("%d", task.MaxNodeCount)
+			task.MaxNodeCount = modification.To.(int)
+		case proto.ModifyTargetScope:
+			oldValue = task.TargetScope
+			task.TargetScope = modification.To.(string)
+		case proto.ModifyPriority:
+			oldValue = fmt.Sprintf("%d", task.Priority)
+			task.Priority = modification.To.(int)
+		}
+		if err := m.taskMgr.AppendTaskEvent(ctx, task.ID, proto.EventModified,
+			oldValue, fmt.Sprintf("%v", modification.To)); err != nil {
+			return err
+		}
+	}
+	if spec, ok := GetTaskTypeSpec(task.Type); ok && spec.OnModify != nil {
+		if err := spec.OnModify(ctx, task, param.Modifications); err != nil {
+			return err
+		}
+	}
+
+	task.State = param.PrevState
+	return m.taskMgr.ModifiedTask(ctx, task)
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+// TriggerTaskManager is the subset of storage.TaskManager the TriggerManager
+// depends on.
+type TriggerTaskManager interface {
+	GetDueTriggers(ctx context.Context, now int64) ([]*proto.Trigger, error)
+	UpdateTrigger(ctx context.Context, trigger *proto.Trigger) error
+	GetTaskBaseByID(ctx context.Context, taskID int64) (*proto.TaskBase, error)
+	CreateTask(ctx context.Context, task *proto.Task) (int64, error)
+	CancelTask(ctx context.Context, key string) (found bool, err error)
+}
+
+// TriggerManager runs on the owner node and, on every tick, submits tasks
+// for any trigger whose schedule has come due.
+type TriggerManager struct {
+	taskMgr TriggerTaskManager
+	// clock returns the current time; overridden in tests so a trigger's
+	// schedule can be fast-forwarded deterministically.
+	clock func() time.Time
+}
+
+// NewTriggerManager creates a TriggerManager backed by taskMgr, using the
+// wall clock.
+func NewTriggerManager(taskMgr TriggerTaskManager) *TriggerManager {
+	return &TriggerManager{taskMgr: taskMgr, clock: time.Now}
+}
+
+// SetClock overrides the manager's notion of "now", for tests.
+func (m *TriggerManager) SetClock(clock func() time.Time) {
+	m.clock = clock
+}
+
+// Tick fires every trigger that is due, advancing each to its next
+// scheduled time. It is meant to be called once per owner tick.
+func (m *TriggerManager) Tick(ctx context.Context) error {
+	now := m.clock()
+	due, err := m.taskMgr.GetDueTriggers(ctx, now.Unix())
+	if err != nil {
+		return err
+	}
+	for _, trigger := range due {
+		if err := m.fire(ctx, trigger, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *TriggerManager) fire(ctx context.Context, trigger *proto.Trigger, now time.Time) error {
+	if trigger.LastTaskID != 0 && trigger.OnOverlap != proto.OverlapQueue {
+		prev, err := m.taskMgr.GetTaskBaseByID(ctx, trigger.LastTaskID)
+		if err != nil {
+			return err
+		}
+		if prev != nil && !prev.IsDone() {
+			switch trigger.OnOverlap {
+			case proto.OverlapSkip:
+				return m.advance(ctx, trigger, now)
+			case proto.OverlapCancelAndReplace:
+				if _, err := m.taskMgr.CancelTask(ctx, prev.Key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	key := fmt.Sprintf("%s-%d", trigger.KeyPrefix, now.Unix())
+	task := &proto.Task{
+		TaskBase: proto.TaskBase{
+			Key:         key,
+			Type:        trigger.TaskType,
+			State:       proto.TaskStatePending,
+			Step:        proto.StepInit,
+			Concurrency: trigger.Concurrency,
+		},
+		Meta: trigger.Meta,
+	}
+	id, err := m.taskMgr.CreateTask(ctx, task)
+	if err != nil {
+		return err
+	}
+	trigger.LastTaskID = id
+	return m.advance(ctx, trigger, now)
+}
+
+func (m *TriggerManager) advance(ctx context.Context, trigger *proto.Trigger, now time.Time) error {
+	sched, err := parseCronExpr(trigger.CronExpr)
+	if err != nil {
+		return err
+	}
+	trigger.NextFireAt = sched.next(now)
+	return m.taskMgr.UpdateTrigger(ctx, trigger)
+}
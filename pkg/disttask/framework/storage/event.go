@@ -0,0 +1,210 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+// terminalEventTTL is the retention period for a terminal task's events.
+// Nothing in this package applies it: GetTaskEvents returns a task's events
+// regardless of their age, and no rows are deleted here.
+const terminalEventTTL = 7 * 24 * time.Hour
+
+// eventPollInterval is how often a SubscribeTaskEvents subscription with a
+// TaskID filter polls storage for that task's events, so it also sees events
+// appended on other nodes, which this node's in-memory fan-out never gets.
+const eventPollInterval = 200 * time.Millisecond
+
+// AppendTaskEvent appends one entry to taskID's event log with the next
+// sequence number, then publishes it to every local subscriber.
+func (mgr *TaskManager) AppendTaskEvent(ctx context.Context, taskID int64, kind proto.EventKind, oldValue, newValue string) error {
+	now := time.Now()
+	if err := mgr.executor.ExecuteSQL(ctx,
+		`insert into mysql.tidb_dxf_task_event (task_id, seq, kind, old_value, new_value, create_time)
+		 select %?, coalesce(max(seq), 0) + 1, %?, %?, %?, %?
+		 from mysql.tidb_dxf_task_event where task_id = %?`,
+		taskID, kind, oldValue, newValue, now, taskID); err != nil {
+		return err
+	}
+	row, err := mgr.executor.QueryRow(ctx,
+		`select seq from mysql.tidb_dxf_task_event
+		 where task_id = %? order by seq desc limit 1`, taskID)
+	if err != nil {
+		return err
+	}
+	seq, _ := row[0].(int64)
+	mgr.events.publish(proto.TaskEvent{
+		TaskID:   taskID,
+		Seq:      seq,
+		Kind:     kind,
+		OldValue: oldValue,
+		NewValue: newValue,
+		Time:     now,
+	})
+	return nil
+}
+
+// GetTaskEvents returns every event recorded for taskID, oldest first.
+func (mgr *TaskManager) GetTaskEvents(ctx context.Context, taskID int64) ([]*proto.TaskEvent, error) {
+	rows, err := mgr.executor.QueryRows(ctx,
+		`select task_id, seq, kind, old_value, new_value, create_time
+		 from mysql.tidb_dxf_task_event
+		 where task_id = %? order by seq asc`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*proto.TaskEvent, 0, len(rows))
+	for _, row := range rows {
+		ev, err := row2TaskEvent(row)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+func row2TaskEvent(row []any) (*proto.TaskEvent, error) {
+	if len(row) != 6 {
+		return nil, fmt.Errorf("task event row: want 6 columns, got %d", len(row))
+	}
+	taskID, ok := row[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("column task_id: want int64, got %T", row[0])
+	}
+	seq, ok := row[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("column seq: want int64, got %T", row[1])
+	}
+	kind, ok := row[2].(proto.EventKind)
+	if !ok {
+		return nil, fmt.Errorf("column kind: want proto.EventKind, got %T", row[2])
+	}
+	oldValue, ok := row[3].(string)
+	if !ok {
+		return nil, fmt.Errorf("column old_value: want string, got %T", row[3])
+	}
+	newValue, ok := row[4].(string)
+	if !ok {
+		return nil, fmt.Errorf("column new_value: want string, got %T", row[4])
+	}
+	createTime, ok := row[5].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("column create_time: want time.Time, got %T", row[5])
+	}
+	return &proto.TaskEvent{
+		TaskID:   taskID,
+		Seq:      seq,
+		Kind:     kind,
+		OldValue: oldValue,
+		NewValue: newValue,
+		Time:     createTime,
+	}, nil
+}
+
+// SubscribeTaskEvents returns a channel of events matching filter. Events
+// appended through this node's TaskManager are fanned out as soon as
+// AppendTaskEvent is called. When filter.TaskID is set, a polling goroutine
+// also reads that task's log from storage every eventPollInterval, which
+// delivers events appended on other nodes; it starts from the beginning of
+// the log and does not skip events already pushed by the fan-out. When
+// filter.TaskID is 0 there is no polling, so only events appended on this
+// node are delivered. The channel is closed when ctx is done.
+func (mgr *TaskManager) SubscribeTaskEvents(ctx context.Context, filter proto.EventFilter) <-chan proto.TaskEvent {
+	ch := make(chan proto.TaskEvent, 64)
+	unsubscribe := mgr.events.subscribe(filter, ch)
+
+	go func() {
+		defer unsubscribe()
+		defer close(ch)
+
+		var lastSeq int64
+		ticker := time.NewTicker(eventPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if filter.TaskID == 0 {
+					// polling fallback only makes sense for a single task's
+					// log, where "new since lastSeq" is well defined.
+					continue
+				}
+				events, err := mgr.GetTaskEvents(ctx, filter.TaskID)
+				if err != nil {
+					continue
+				}
+				for _, ev := range events {
+					if ev.Seq <= lastSeq || !filter.Match(*ev) {
+						continue
+					}
+					lastSeq = ev.Seq
+					select {
+					case ch <- *ev:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return ch
+}
+
+// eventBroker fans out newly appended events to local SubscribeTaskEvents
+// callers on the owner node, without waiting on the polling fallback.
+type eventBroker struct {
+	mu   sync.Mutex
+	subs map[chan<- proto.TaskEvent]proto.EventFilter
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subs: make(map[chan<- proto.TaskEvent]proto.EventFilter)}
+}
+
+func (b *eventBroker) subscribe(filter proto.EventFilter, ch chan<- proto.TaskEvent) (unsubscribe func()) {
+	b.mu.Lock()
+	b.subs[ch] = filter
+	b.mu.Unlock()
+	return func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+}
+
+func (b *eventBroker) publish(ev proto.TaskEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch, filter := range b.subs {
+		if !filter.Match(ev) {
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+			// slow subscriber: the event is dropped here; a subscriber with
+			// a TaskID filter gets it later from the polling fallback.
+		}
+	}
+}
@@ -0,0 +1,431 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage implements persistence for the disttask framework (DXF):
+// tasks, subtasks and the auxiliary tables used to drive scheduling.
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+// sqlExecutor is the minimal surface TaskManager needs from a SQL session.
+// It is satisfied by the internal restricted-SQL executor used elsewhere in
+// the server; kept narrow here so tests can stub it out.
+type sqlExecutor interface {
+	ExecuteSQL(ctx context.Context, sql string, args ...any) error
+	QueryRow(ctx context.Context, sql string, args ...any) (row []any, err error)
+	QueryRows(ctx context.Context, sql string, args ...any) (rows [][]any, err error)
+}
+
+// TaskManager is the single entry point the scheduler, the executor and
+// `handle` use to read and mutate task/subtask state in the system tables.
+type TaskManager struct {
+	executor sqlExecutor
+	events   *eventBroker
+}
+
+// NewTaskManager creates a TaskManager backed by the given executor.
+func NewTaskManager(executor sqlExecutor) *TaskManager {
+	return &TaskManager{executor: executor, events: newEventBroker()}
+}
+
+// CreateTask inserts a new task row and returns its allocated ID. If
+// task.CreateTime is zero, it is stamped with the current time before
+// insertion so the priority scheduler has something to age from.
+func (mgr *TaskManager) CreateTask(ctx context.Context, task *proto.Task) (int64, error) {
+	if task.CreateTime.IsZero() {
+		task.CreateTime = time.Now()
+	}
+	if err := mgr.executor.ExecuteSQL(ctx,
+		`insert into mysql.tidb_global_task
+			(task_key, type, state, step, priority, concurrency, max_node_count, target_scope, meta, create_time)
+		 values (%?, %?, %?, %?, %?, %?, %?, %?, %?, %?)`,
+		task.Key, task.Type, task.State, task.Step, task.Priority, task.Concurrency,
+		task.MaxNodeCount, task.TargetScope, task.Meta, task.CreateTime); err != nil {
+		return 0, err
+	}
+	row, err := mgr.executor.QueryRow(ctx, `select last_insert_id()`)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := row[0].(int64)
+	return id, nil
+}
+
+// GetTaskBaseByID returns the task's cheap-to-read fields without its Meta.
+func (mgr *TaskManager) GetTaskBaseByID(ctx context.Context, taskID int64) (*proto.TaskBase, error) {
+	row, err := mgr.executor.QueryRow(ctx,
+		`select id, task_key, type, state, step, priority, concurrency,
+			max_node_count, target_scope, create_time, paused_reason
+		 from mysql.tidb_global_task where id = %?`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return row2TaskBase(row)
+}
+
+// GetTaskBaseByKey returns the task's cheap-to-read fields without its Meta,
+// looked up by its submission key rather than ID.
+func (mgr *TaskManager) GetTaskBaseByKey(ctx context.Context, key string) (*proto.TaskBase, error) {
+	row, err := mgr.executor.QueryRow(ctx,
+		`select id, task_key, type, state, step, priority, concurrency,
+			max_node_count, target_scope, create_time, paused_reason
+		 from mysql.tidb_global_task where task_key = %?`, key)
+	if err != nil {
+		return nil, err
+	}
+	return row2TaskBase(row)
+}
+
+// GetTaskByID returns the full task, including its Meta.
+func (mgr *TaskManager) GetTaskByID(ctx context.Context, taskID int64) (*proto.Task, error) {
+	row, err := mgr.executor.QueryRow(ctx,
+		`select id, task_key, type, state, step, priority, concurrency,
+			max_node_count, target_scope, create_time, paused_reason, meta
+		 from mysql.tidb_global_task where id = %?`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	base, err := row2TaskBase(row[:11])
+	if err != nil {
+		return nil, err
+	}
+	task := &proto.Task{TaskBase: *base}
+	if row[11] != nil {
+		meta, ok := row[11].([]byte)
+		if !ok {
+			return nil, fmt.Errorf("column meta: want []byte, got %T", row[11])
+		}
+		task.Meta = meta
+	}
+	return task, nil
+}
+
+// ListTasksByState returns every task currently in state, oldest first.
+func (mgr *TaskManager) ListTasksByState(ctx context.Context, state proto.TaskState) ([]*proto.TaskBase, error) {
+	rows, err := mgr.executor.QueryRows(ctx,
+		`select id, task_key, type, state, step, priority, concurrency,
+			max_node_count, target_scope, create_time, paused_reason
+		 from mysql.tidb_global_task where state = %? order by id asc`, state)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]*proto.TaskBase, 0, len(rows))
+	for _, row := range rows {
+		task, err := row2TaskBase(row)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// UpdateTaskState unconditionally moves task taskID to state at step. It is
+// used by the scheduler once it has already decided the transition is
+// valid, e.g. starting a schedulable task or advancing it to its next step.
+func (mgr *TaskManager) UpdateTaskState(ctx context.Context, taskID int64, state proto.TaskState, step proto.Step) error {
+	return mgr.executor.ExecuteSQL(ctx,
+		`update mysql.tidb_global_task set state = %?, step = %? where id = %?`,
+		state, step, taskID)
+}
+
+func row2TaskBase(row []any) (*proto.TaskBase, error) {
+	if len(row) < 11 {
+		return nil, fmt.Errorf("task row: want at least 11 columns, got %d", len(row))
+	}
+	id, ok := row[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("column id: want int64, got %T", row[0])
+	}
+	key, ok := row[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("column task_key: want string, got %T", row[1])
+	}
+	taskType, ok := row[2].(proto.TaskType)
+	if !ok {
+		return nil, fmt.Errorf("column type: want proto.TaskType, got %T", row[2])
+	}
+	state, ok := row[3].(proto.TaskState)
+	if !ok {
+		return nil, fmt.Errorf("column state: want proto.TaskState, got %T", row[3])
+	}
+	step, ok := row[4].(proto.Step)
+	if !ok {
+		return nil, fmt.Errorf("column step: want proto.Step, got %T", row[4])
+	}
+	priority, ok := row[5].(int)
+	if !ok {
+		return nil, fmt.Errorf("column priority: want int, got %T", row[5])
+	}
+	concurrency, ok := row[6].(int)
+	if !ok {
+		return nil, fmt.Errorf("column concurrency: want int, got %T", row[6])
+	}
+	maxNodeCount, ok := row[7].(int)
+	if !ok {
+		return nil, fmt.Errorf("column max_node_count: want int, got %T", row[7])
+	}
+	targetScope, ok := row[8].(string)
+	if !ok {
+		return nil, fmt.Errorf("column target_scope: want string, got %T", row[8])
+	}
+	createTime, ok := row[9].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("column create_time: want time.Time, got %T", row[9])
+	}
+	pausedReason, ok := row[10].(proto.PausedReason)
+	if !ok {
+		return nil, fmt.Errorf("column paused_reason: want proto.PausedReason, got %T", row[10])
+	}
+	return &proto.TaskBase{
+		ID:           id,
+		Key:          key,
+		Type:         taskType,
+		State:        state,
+		Step:         step,
+		Priority:     priority,
+		Concurrency:  concurrency,
+		MaxNodeCount: maxNodeCount,
+		TargetScope:  targetScope,
+		CreateTime:   createTime,
+		PausedReason: pausedReason,
+	}, nil
+}
+
+// ModifyTaskByID moves a task into TaskStateModifying and stores param so
+// that the scheduler can pick it up at the next step boundary. It is a no-op
+// once another owner has already started (or finished) the same
+// modification, which is detected by PrevState no longer matching the
+// persisted state.
+func (mgr *TaskManager) ModifyTaskByID(ctx context.Context, taskID int64, param *proto.ModifyParam) error {
+	if err := param.Validate(); err != nil {
+		return err
+	}
+	paramBytes, err := json.Marshal(param)
+	if err != nil {
+		return fmt.Errorf("marshal modify param: %w", err)
+	}
+	return mgr.executor.ExecuteSQL(ctx,
+		`update mysql.tidb_global_task
+		 set state = %?, modify_params = %?
+		 where id = %? and state = %?`,
+		proto.TaskStateModifying, paramBytes, taskID, param.PrevState)
+}
+
+// ModifiedTask is called by the scheduler once it has applied every
+// Modification in the task's stored ModifyParam; it persists the new field
+// values and restores the task to ModifyParam.PrevState.
+func (mgr *TaskManager) ModifiedTask(ctx context.Context, task *proto.Task) error {
+	return mgr.executor.ExecuteSQL(ctx,
+		`update mysql.tidb_global_task
+		 set state = %?, priority = %?, concurrency = %?, max_node_count = %?, target_scope = %?,
+			 modify_params = null
+		 where id = %? and state = %?`,
+		task.State, task.Priority, task.Concurrency, task.MaxNodeCount, task.TargetScope,
+		task.ID, proto.TaskStateModifying)
+}
+
+// PauseTask transitions the task identified by key to TaskStatePausing if it
+// is currently in a pausable state, recording reason so the scheduler can
+// later tell whether it is safe to resume automatically. found reports
+// whether such a task existed.
+func (mgr *TaskManager) PauseTask(ctx context.Context, key string, reason proto.PausedReason) (found bool, err error) {
+	if err := mgr.executor.ExecuteSQL(ctx,
+		`update mysql.tidb_global_task
+		 set state = %?, paused_reason = %?
+		 where task_key = %? and state in (%?, %?)`,
+		proto.TaskStatePausing, reason, key, proto.TaskStatePending, proto.TaskStateRunning); err != nil {
+		return false, err
+	}
+	task, err := mgr.GetTaskBaseByKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return true, mgr.AppendTaskEvent(ctx, task.ID, proto.EventPaused, "", "")
+}
+
+// ResumeTask transitions a paused task back to TaskStateRunning, clearing
+// PausedReason.
+func (mgr *TaskManager) ResumeTask(ctx context.Context, key string) (found bool, err error) {
+	if err := mgr.executor.ExecuteSQL(ctx,
+		`update mysql.tidb_global_task
+		 set state = %?, paused_reason = %?
+		 where task_key = %? and state = %?`,
+		proto.TaskStateRunning, proto.PausedReason(""), key, proto.TaskStatePaused); err != nil {
+		return false, err
+	}
+	task, err := mgr.GetTaskBaseByKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return true, mgr.AppendTaskEvent(ctx, task.ID, proto.EventResumed, "", "")
+}
+
+// CancelTask transitions the task identified by key to TaskStateCancelled,
+// unless it has already reached a terminal state. found reports whether such
+// a task existed and was still cancellable.
+func (mgr *TaskManager) CancelTask(ctx context.Context, key string) (found bool, err error) {
+	if err := mgr.executor.ExecuteSQL(ctx,
+		`update mysql.tidb_global_task
+		 set state = %?
+		 where task_key = %? and state not in (%?, %?, %?)`,
+		proto.TaskStateCancelled, key,
+		proto.TaskStateSucceed, proto.TaskStateFailed, proto.TaskStateCancelled); err != nil {
+		return false, err
+	}
+	task, err := mgr.GetTaskBaseByKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if task.State != proto.TaskStateCancelled {
+		return false, nil
+	}
+	return true, mgr.AppendTaskEvent(ctx, task.ID, proto.EventCancelled, "", "")
+}
+
+// CreateSubtasks inserts one row per subtask, all belonging to the same
+// task and step.
+func (mgr *TaskManager) CreateSubtasks(ctx context.Context, subtasks []*proto.Subtask) error {
+	for _, subtask := range subtasks {
+		if err := mgr.executor.ExecuteSQL(ctx,
+			`insert into mysql.tidb_background_subtask
+				(task_id, step, type, concurrency, exec_id, meta, state)
+			 values (%?, %?, %?, %?, %?, %?, %?)`,
+			subtask.TaskID, subtask.Step, subtask.Type, subtask.Concurrency,
+			subtask.ExecID, subtask.Meta, subtask.State); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSubtasks returns the live (not yet archived to history) subtasks for
+// taskID/step.
+func (mgr *TaskManager) GetSubtasks(ctx context.Context, taskID int64, step proto.Step) ([]*proto.Subtask, error) {
+	rows, err := mgr.executor.QueryRows(ctx,
+		`select id, task_id, step, type, concurrency, exec_id, meta, state
+		 from mysql.tidb_background_subtask where task_id = %? and step = %?`,
+		taskID, step)
+	if err != nil {
+		return nil, err
+	}
+	return rows2Subtasks(rows)
+}
+
+// GetPendingSubtasksForExecID returns the subtasks assigned to execID that
+// have not yet started running, across all tasks.
+func (mgr *TaskManager) GetPendingSubtasksForExecID(ctx context.Context, execID string) ([]*proto.Subtask, error) {
+	rows, err := mgr.executor.QueryRows(ctx,
+		`select id, task_id, step, type, concurrency, exec_id, meta, state
+		 from mysql.tidb_background_subtask where exec_id = %? and state = %?`,
+		execID, proto.TaskStatePending)
+	if err != nil {
+		return nil, err
+	}
+	return rows2Subtasks(rows)
+}
+
+// UpdateSubtaskState persists a subtask's new state, e.g. once its Runner
+// returns.
+func (mgr *TaskManager) UpdateSubtaskState(ctx context.Context, subtaskID int64, state proto.TaskState) error {
+	return mgr.executor.ExecuteSQL(ctx,
+		`update mysql.tidb_background_subtask set state = %? where id = %?`,
+		state, subtaskID)
+}
+
+// GetSubtasksWithHistory returns all subtasks belonging to taskID/step,
+// including ones already moved to the history table once the step finished.
+func (mgr *TaskManager) GetSubtasksWithHistory(ctx context.Context, taskID int64, step proto.Step) ([]*proto.Subtask, error) {
+	rows, err := mgr.executor.QueryRows(ctx,
+		`select id, task_id, step, type, concurrency, exec_id, meta, state
+		 from mysql.tidb_background_subtask where task_id = %? and step = %?
+		 union all
+		 select id, task_id, step, type, concurrency, exec_id, meta, state
+		 from mysql.tidb_background_subtask_history where task_id = %? and step = %?`,
+		taskID, step, taskID, step)
+	if err != nil {
+		return nil, err
+	}
+	return rows2Subtasks(rows)
+}
+
+func rows2Subtasks(rows [][]any) ([]*proto.Subtask, error) {
+	subtasks := make([]*proto.Subtask, 0, len(rows))
+	for _, row := range rows {
+		subtask, err := row2Subtask(row)
+		if err != nil {
+			return nil, err
+		}
+		subtasks = append(subtasks, subtask)
+	}
+	return subtasks, nil
+}
+
+func row2Subtask(row []any) (*proto.Subtask, error) {
+	if len(row) != 8 {
+		return nil, fmt.Errorf("subtask row: want 8 columns, got %d", len(row))
+	}
+	id, ok := row[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("column id: want int64, got %T", row[0])
+	}
+	taskID, ok := row[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("column task_id: want int64, got %T", row[1])
+	}
+	step, ok := row[2].(proto.Step)
+	if !ok {
+		return nil, fmt.Errorf("column step: want proto.Step, got %T", row[2])
+	}
+	taskType, ok := row[3].(proto.TaskType)
+	if !ok {
+		return nil, fmt.Errorf("column type: want proto.TaskType, got %T", row[3])
+	}
+	concurrency, ok := row[4].(int)
+	if !ok {
+		return nil, fmt.Errorf("column concurrency: want int, got %T", row[4])
+	}
+	execID, ok := row[5].(string)
+	if !ok {
+		return nil, fmt.Errorf("column exec_id: want string, got %T", row[5])
+	}
+	var meta []byte
+	if row[6] != nil {
+		meta, ok = row[6].([]byte)
+		if !ok {
+			return nil, fmt.Errorf("column meta: want []byte, got %T", row[6])
+		}
+	}
+	state, ok := row[7].(proto.TaskState)
+	if !ok {
+		return nil, fmt.Errorf("column state: want proto.TaskState, got %T", row[7])
+	}
+	return &proto.Subtask{
+		ID:          id,
+		TaskID:      taskID,
+		Step:        step,
+		Type:        taskType,
+		Concurrency: concurrency,
+		ExecID:      execID,
+		Meta:        meta,
+		State:       state,
+	}, nil
+}
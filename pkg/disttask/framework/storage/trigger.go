@@ -0,0 +1,170 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+// CreateTrigger inserts a new row into `tidb_dxf_trigger` and returns its
+// allocated ID.
+func (mgr *TaskManager) CreateTrigger(ctx context.Context, trigger *proto.Trigger) (int64, error) {
+	if err := mgr.executor.ExecuteSQL(ctx,
+		`insert into mysql.tidb_dxf_trigger
+			(key_prefix, task_type, cron_expr, concurrency, meta, next_fire_at, enabled, on_overlap)
+		 values (%?, %?, %?, %?, %?, %?, %?, %?)`,
+		trigger.KeyPrefix, trigger.TaskType, trigger.CronExpr, trigger.Concurrency,
+		trigger.Meta, trigger.NextFireAt, trigger.Enabled, trigger.OnOverlap); err != nil {
+		return 0, err
+	}
+	row, err := mgr.executor.QueryRow(ctx, `select last_insert_id()`)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := row[0].(int64)
+	return id, nil
+}
+
+// UpdateTrigger persists every field of trigger, keyed by trigger.ID.
+func (mgr *TaskManager) UpdateTrigger(ctx context.Context, trigger *proto.Trigger) error {
+	return mgr.executor.ExecuteSQL(ctx,
+		`update mysql.tidb_dxf_trigger
+		 set key_prefix = %?, task_type = %?, cron_expr = %?, concurrency = %?,
+			 meta = %?, next_fire_at = %?, last_task_id = %?, enabled = %?, on_overlap = %?
+		 where id = %?`,
+		trigger.KeyPrefix, trigger.TaskType, trigger.CronExpr, trigger.Concurrency,
+		trigger.Meta, trigger.NextFireAt, trigger.LastTaskID, trigger.Enabled,
+		trigger.OnOverlap, trigger.ID)
+}
+
+// DeleteTrigger removes the trigger row with the given ID.
+func (mgr *TaskManager) DeleteTrigger(ctx context.Context, id int64) error {
+	return mgr.executor.ExecuteSQL(ctx, `delete from mysql.tidb_dxf_trigger where id = %?`, id)
+}
+
+// ListTriggers returns every trigger row, enabled or not.
+func (mgr *TaskManager) ListTriggers(ctx context.Context) ([]*proto.Trigger, error) {
+	rows, err := mgr.executor.QueryRows(ctx,
+		`select id, key_prefix, task_type, cron_expr, concurrency, meta,
+			next_fire_at, last_task_id, enabled, on_overlap
+		 from mysql.tidb_dxf_trigger`)
+	if err != nil {
+		return nil, err
+	}
+	return rows2Triggers(rows)
+}
+
+// GetDueTriggers locks and returns every enabled trigger whose next_fire_at
+// has passed, using `FOR UPDATE SKIP LOCKED` so concurrent owner ticks on
+// different TiDB instances never fire the same trigger twice.
+func (mgr *TaskManager) GetDueTriggers(ctx context.Context, now int64) ([]*proto.Trigger, error) {
+	rows, err := mgr.executor.QueryRows(ctx,
+		`select id, key_prefix, task_type, cron_expr, concurrency, meta,
+			next_fire_at, last_task_id, enabled, on_overlap
+		 from mysql.tidb_dxf_trigger
+		 where enabled = 1 and next_fire_at <= %?
+		 for update skip locked`, now)
+	if err != nil {
+		return nil, err
+	}
+	return rows2Triggers(rows)
+}
+
+// ModifyTrigger updates a trigger's schedule/concurrency/meta/enabled flag in
+// one call, mirroring ModifyTaskByID's single entry point for task edits.
+func (mgr *TaskManager) ModifyTrigger(ctx context.Context, id int64, cronExpr string, concurrency int, meta []byte, enabled bool) error {
+	return mgr.executor.ExecuteSQL(ctx,
+		`update mysql.tidb_dxf_trigger
+		 set cron_expr = %?, concurrency = %?, meta = %?, enabled = %?
+		 where id = %?`,
+		cronExpr, concurrency, meta, enabled, id)
+}
+
+func rows2Triggers(rows [][]any) ([]*proto.Trigger, error) {
+	triggers := make([]*proto.Trigger, 0, len(rows))
+	for _, row := range rows {
+		trigger, err := row2Trigger(row)
+		if err != nil {
+			return nil, err
+		}
+		triggers = append(triggers, trigger)
+	}
+	return triggers, nil
+}
+
+func row2Trigger(row []any) (*proto.Trigger, error) {
+	if len(row) != 10 {
+		return nil, fmt.Errorf("trigger row: want 10 columns, got %d", len(row))
+	}
+	id, ok := row[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("column id: want int64, got %T", row[0])
+	}
+	keyPrefix, ok := row[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("column key_prefix: want string, got %T", row[1])
+	}
+	taskType, ok := row[2].(proto.TaskType)
+	if !ok {
+		return nil, fmt.Errorf("column task_type: want proto.TaskType, got %T", row[2])
+	}
+	cronExpr, ok := row[3].(string)
+	if !ok {
+		return nil, fmt.Errorf("column cron_expr: want string, got %T", row[3])
+	}
+	concurrency, ok := row[4].(int)
+	if !ok {
+		return nil, fmt.Errorf("column concurrency: want int, got %T", row[4])
+	}
+	var meta []byte
+	if row[5] != nil {
+		meta, ok = row[5].([]byte)
+		if !ok {
+			return nil, fmt.Errorf("column meta: want []byte, got %T", row[5])
+		}
+	}
+	nextFireAt, ok := row[6].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("column next_fire_at: want time.Time, got %T", row[6])
+	}
+	lastTaskID, ok := row[7].(int64)
+	if !ok {
+		return nil, fmt.Errorf("column last_task_id: want int64, got %T", row[7])
+	}
+	enabled, ok := row[8].(bool)
+	if !ok {
+		return nil, fmt.Errorf("column enabled: want bool, got %T", row[8])
+	}
+	onOverlap, ok := row[9].(proto.OverlapPolicy)
+	if !ok {
+		return nil, fmt.Errorf("column on_overlap: want proto.OverlapPolicy, got %T", row[9])
+	}
+	return &proto.Trigger{
+		ID:          id,
+		KeyPrefix:   keyPrefix,
+		TaskType:    taskType,
+		CronExpr:    cronExpr,
+		Concurrency: concurrency,
+		Meta:        meta,
+		NextFireAt:  nextFireAt,
+		LastTaskID:  lastTaskID,
+		Enabled:     enabled,
+		OnOverlap:   onOverlap,
+	}, nil
+}
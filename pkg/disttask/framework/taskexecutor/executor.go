@@ -0,0 +1,143 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskexecutor
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+// TaskManager is the subset of storage.TaskManager the executor depends on.
+type TaskManager interface {
+	GetTaskBaseByID(ctx context.Context, taskID int64) (*proto.TaskBase, error)
+	GetPendingSubtasksForExecID(ctx context.Context, execID string) ([]*proto.Subtask, error)
+	UpdateSubtaskState(ctx context.Context, subtaskID int64, state proto.TaskState) error
+	AppendTaskEvent(ctx context.Context, taskID int64, kind proto.EventKind, oldValue, newValue string) error
+}
+
+// Executor runs, on one node, the subtasks the scheduler assigned to execID.
+type Executor struct {
+	execID  string
+	taskMgr TaskManager
+	slots   *slotManager
+
+	mu      sync.Mutex
+	running map[int64]runningSubtask
+}
+
+type runningSubtask struct {
+	taskID int64
+	cancel context.CancelFunc
+}
+
+// NewExecutor creates an Executor that runs subtasks assigned to execID.
+func NewExecutor(execID string, taskMgr TaskManager) *Executor {
+	return &Executor{
+		execID:  execID,
+		taskMgr: taskMgr,
+		slots:   newSlotManager(),
+		running: make(map[int64]runningSubtask),
+	}
+}
+
+// RunOnce interrupts any subtask whose task has moved to TaskStatePausing,
+// then dispatches every newly assigned subtask that fits in this node's
+// slots to its registered Runner. Subtasks run in their own goroutine;
+// RunOnce itself never blocks on one finishing.
+func (e *Executor) RunOnce(ctx context.Context) error {
+	if err := e.cancelPausingSubtasks(ctx); err != nil {
+		return err
+	}
+
+	subtasks, err := e.taskMgr.GetPendingSubtasksForExecID(ctx, e.execID)
+	if err != nil {
+		return err
+	}
+	for _, subtask := range subtasks {
+		task, err := e.taskMgr.GetTaskBaseByID(ctx, subtask.TaskID)
+		if err != nil {
+			return err
+		}
+		spec, ok := getTaskExecutorSpec(task.Type)
+		if !ok {
+			continue
+		}
+		if !e.slots.canReserve(task, subtask.Concurrency) {
+			continue
+		}
+		e.dispatch(ctx, task, subtask, spec.Runner)
+	}
+	return nil
+}
+
+func (e *Executor) cancelPausingSubtasks(ctx context.Context) error {
+	e.mu.Lock()
+	taskIDs := make(map[int64]struct{}, len(e.running))
+	for _, rs := range e.running {
+		taskIDs[rs.taskID] = struct{}{}
+	}
+	e.mu.Unlock()
+
+	for taskID := range taskIDs {
+		task, err := e.taskMgr.GetTaskBaseByID(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		if task.State != proto.TaskStatePausing {
+			continue
+		}
+		e.mu.Lock()
+		for subtaskID, rs := range e.running {
+			if rs.taskID == taskID {
+				rs.cancel()
+				delete(e.running, subtaskID)
+			}
+		}
+		e.mu.Unlock()
+	}
+	return nil
+}
+
+func (e *Executor) dispatch(ctx context.Context, task *proto.TaskBase, subtask *proto.Subtask, runner SubtaskRunner) {
+	e.slots.reserve(task, subtask.Concurrency)
+	if err := e.taskMgr.UpdateSubtaskState(ctx, subtask.ID, proto.TaskStateRunning); err != nil {
+		e.slots.release(task, subtask.Concurrency)
+		return
+	}
+	subCtx, cancel := context.WithCancel(ctx)
+	e.mu.Lock()
+	e.running[subtask.ID] = runningSubtask{taskID: task.ID, cancel: cancel}
+	e.mu.Unlock()
+
+	go func() {
+		defer func() {
+			e.slots.release(task, subtask.Concurrency)
+			e.mu.Lock()
+			delete(e.running, subtask.ID)
+			e.mu.Unlock()
+		}()
+		runErr := runner(subCtx, subtask)
+		// ctx (not subCtx) persists the result, since subCtx may already be
+		// cancelled by the time the runner returns.
+		if runErr != nil {
+			_ = e.taskMgr.UpdateSubtaskState(ctx, subtask.ID, proto.TaskStateFailed)
+			_ = e.taskMgr.AppendTaskEvent(ctx, task.ID, proto.EventSubtaskFailed, "", runErr.Error())
+			return
+		}
+		_ = e.taskMgr.UpdateSubtaskState(ctx, subtask.ID, proto.TaskStateSucceed)
+	}()
+}
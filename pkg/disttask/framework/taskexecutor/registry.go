@@ -0,0 +1,54 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskexecutor
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+// SubtaskRunner executes a single subtask of a custom task type.
+type SubtaskRunner func(ctx context.Context, subtask *proto.Subtask) error
+
+// ExecutorSpec is what an external Go module supplies so this node can run
+// subtasks of a custom task type.
+type ExecutorSpec struct {
+	Type   proto.TaskType
+	Runner SubtaskRunner
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[proto.TaskType]ExecutorSpec{}
+)
+
+// RegisterTaskExecutor registers spec.Runner for spec.Type on this node.
+// Idempotent: registering the same type again replaces the previous spec.
+// Safe for concurrent use.
+func RegisterTaskExecutor(spec ExecutorSpec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[spec.Type] = spec
+}
+
+// getTaskExecutorSpec returns the spec registered for taskType, if any.
+func getTaskExecutorSpec(taskType proto.TaskType) (ExecutorSpec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	spec, ok := registry[taskType]
+	return spec, ok
+}
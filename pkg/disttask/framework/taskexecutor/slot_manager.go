@@ -0,0 +1,66 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taskexecutor runs the subtasks assigned to this node for tasks
+// registered with the DXF.
+package taskexecutor
+
+import (
+	"sync"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+// slotManager tracks how many of this node's slots are reserved per task, so
+// no task holds more than its Concurrency slots on this node at once. There
+// is no node-wide cap across tasks.
+type slotManager struct {
+	mu sync.Mutex
+	// reserved maps task ID to the number of slots currently held on this
+	// node for that task.
+	reserved map[int64]int
+}
+
+func newSlotManager() *slotManager {
+	return &slotManager{reserved: make(map[int64]int)}
+}
+
+// canReserve reports whether count additional slots can be reserved for
+// task on this node without exceeding task.Concurrency. MaxNodeCount and
+// TargetScope are not consulted.
+func (sm *slotManager) canReserve(task *proto.TaskBase, count int) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.reserved[task.ID]+count <= task.Concurrency
+}
+
+// reserve records count additional slots held for task on this node.
+func (sm *slotManager) reserve(task *proto.TaskBase, count int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.reserved[task.ID] += count
+}
+
+// release returns count slots previously reserved for task on this node,
+// once a subtask finishes or could not be started. A ModifyMaxNodes or
+// ModifyTargetScope modification that drops this node does not release
+// slots of subtasks already running here.
+func (sm *slotManager) release(task *proto.TaskBase, count int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.reserved[task.ID] -= count
+	if sm.reserved[task.ID] <= 0 {
+		delete(sm.reserved, task.ID)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutil provides the scaffolding DXF integration tests build on:
+// an in-process cluster stand-in plus helpers to register mock task types
+// and wait for tasks to reach a given state.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/balancer"
+	"github.com/pingcap/tidb/pkg/disttask/framework/handle"
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+	"github.com/pingcap/tidb/pkg/disttask/framework/scheduler"
+	"github.com/pingcap/tidb/pkg/disttask/framework/storage"
+	"github.com/pingcap/tidb/pkg/disttask/framework/taskexecutor"
+	"go.uber.org/mock/gomock"
+)
+
+// testScopes are the scope tags handed out round-robin across a test
+// cluster's nodes when it has more than one node, so tests that exercise
+// TargetScope/ModifyTargetScope have more than one scope to move between.
+// Single-node clusters leave their node untagged, since those tests never
+// set a TargetScope.
+var testScopes = []string{"scope-a", "scope-b"}
+
+// TestDXFContext bundles everything a DXF integration test needs: a context,
+// a TaskManager talking to the test cluster, and a gomock controller for
+// stubbing out a task type's Scheduler/Executor extensions.
+type TestDXFContext struct {
+	Ctx         context.Context
+	TaskMgr     *storage.TaskManager
+	MockCtrl    *gomock.Controller
+	TestContext *TestContext
+	// Clock is the mockable clock RunTriggerTick hands to the
+	// scheduler.TriggerManager it runs; tests fast-forward trigger schedules
+	// by calling Clock.Advance instead of sleeping real time.
+	Clock *MockClock
+}
+
+// MockClock is an injectable clock for tests that exercise time-based
+// behavior, e.g. trigger firing, without sleeping real wall-clock time.
+type MockClock struct {
+	now time.Time
+}
+
+// NewMockClock returns a MockClock starting at now.
+func NewMockClock(now time.Time) *MockClock {
+	return &MockClock{now: now}
+}
+
+// Now returns the clock's current time; suitable for scheduler.TriggerManager.SetClock.
+func (c *MockClock) Now() time.Time {
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *MockClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// TestContext exposes knobs over the running mock cluster, e.g. the nodes it
+// started, used by registerExampleTask and friends.
+type TestContext struct {
+	NodeIDs []string
+	// NodeScopes maps each node in NodeIDs to the scope it was tagged with,
+	// so tests can assert which nodes a scope-restricted task's subtasks
+	// landed on.
+	NodeScopes map[string]string
+}
+
+// tickInterval is how often the mock cluster's scheduler and executor loops
+// poll the in-memory TaskManager for work.
+const tickInterval = 10 * time.Millisecond
+
+// activeTaskMgr is the TaskManager backing the most recently created
+// TestDXFContext. waitTaskState reads through it rather than threading a
+// TaskManager parameter through testutil.WaitTaskDone's public signature.
+var activeTaskMgr *storage.TaskManager
+
+// NewTestDXFContext starts a mock DXF cluster with nodeCnt nodes and the
+// given per-node subtask concurrency, wires handle.SubmitTask against it,
+// and registers t.Cleanup to tear it down. The cluster's scheduler gets
+// nodeCnt*concurrency subtask slots in total, so a task that fills them makes
+// a later, higher-scoring task preempt it. waitWatcher controls whether the
+// mock cluster's scheduler and executor loops are started; with false no
+// task is driven forward.
+func NewTestDXFContext(t *testing.T, nodeCnt, concurrency int, waitWatcher bool) *TestDXFContext {
+	t.Helper()
+
+	executor := newMockExecutor()
+	taskMgr := storage.NewTaskManager(executor)
+	handle.SetTaskManager(taskMgr)
+	activeTaskMgr = taskMgr
+
+	nodeIDs := make([]string, nodeCnt)
+	nodeScopes := make(map[string]string, nodeCnt)
+	for i := range nodeIDs {
+		nodeIDs[i] = fmt.Sprintf("node%d", i)
+		if nodeCnt > 1 {
+			nodeScopes[nodeIDs[i]] = testScopes[i%len(testScopes)]
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	if waitWatcher {
+		runMockCluster(ctx, taskMgr, nodeIDs, nodeScopes, nodeCnt*concurrency)
+	}
+
+	t.Cleanup(func() { activeTaskMgr = nil })
+
+	return &TestDXFContext{
+		Ctx:         ctx,
+		TaskMgr:     taskMgr,
+		MockCtrl:    gomock.NewController(t),
+		TestContext: &TestContext{NodeIDs: nodeIDs, NodeScopes: nodeScopes},
+		Clock:       NewMockClock(time.Now()),
+	}
+}
+
+// WaitTaskDone blocks until the task identified by key reaches a terminal
+// state and returns its TaskBase.
+func WaitTaskDone(ctx context.Context, t *testing.T, key string) *proto.TaskBase {
+	t.Helper()
+	return waitTaskState(ctx, t, key, func(s proto.TaskState) bool {
+		switch s {
+		case proto.TaskStateSucceed, proto.TaskStateFailed, proto.TaskStateCancelled:
+			return true
+		default:
+			return false
+		}
+	})
+}
+
+// WaitTaskDoneOrPaused blocks until the task identified by key reaches a
+// terminal state or TaskStatePaused, and returns its TaskBase.
+func WaitTaskDoneOrPaused(ctx context.Context, t *testing.T, key string) *proto.TaskBase {
+	t.Helper()
+	return waitTaskState(ctx, t, key, func(s proto.TaskState) bool {
+		switch s {
+		case proto.TaskStatePaused, proto.TaskStateSucceed, proto.TaskStateFailed, proto.TaskStateCancelled:
+			return true
+		default:
+			return false
+		}
+	})
+}
+
+func waitTaskState(ctx context.Context, t *testing.T, key string, done func(proto.TaskState) bool) *proto.TaskBase {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		base, err := activeTaskMgr.GetTaskBaseByKey(ctx, key)
+		if err != nil {
+			t.Fatalf("waitTaskState(%q): %v", key, err)
+		}
+		if base != nil && done(base.State) {
+			return base
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("waitTaskState(%q): timed out waiting for state, last state %v", key, base)
+		}
+		time.Sleep(tickInterval)
+	}
+}
+
+// runMockCluster starts the background scheduler and per-node executor loops
+// that make NewTestDXFContext's TaskManager actually drive tasks to
+// completion, ticking every tickInterval until ctx is cancelled. The
+// scheduler may have concurrencyBudget subtask slots in flight across all
+// nodes.
+func runMockCluster(ctx context.Context, taskMgr *storage.TaskManager, nodeIDs []string, nodeScopes map[string]string, concurrencyBudget int) {
+	nodes := make([]balancer.Node, len(nodeIDs))
+	for i, nodeID := range nodeIDs {
+		nodes[i] = balancer.Node{ID: nodeID, Scope: nodeScopes[nodeID]}
+	}
+	schedMgr := scheduler.NewSchedulerManager(taskMgr, nodes, concurrencyBudget)
+	go runTicker(ctx, schedMgr.ScheduleOnce)
+
+	for _, nodeID := range nodeIDs {
+		executor := taskexecutor.NewExecutor(nodeID, taskMgr)
+		go runTicker(ctx, executor.RunOnce)
+	}
+}
+
+// runTicker calls tick every tickInterval until ctx is cancelled, ignoring
+// errors: the mock cluster has no error-reporting channel, and a failing
+// tick simply leaves the affected task to be retried (or the test to time
+// out waiting on it, which surfaces the underlying bug).
+func runTicker(ctx context.Context, tick func(context.Context) error) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = tick(ctx)
+		}
+	}
+}
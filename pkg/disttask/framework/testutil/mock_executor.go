@@ -0,0 +1,424 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+)
+
+// mockExecutor is an in-memory stand-in for the restricted-SQL executor that
+// storage.TaskManager normally runs against, used to back TestDXFContext.TaskMgr
+// with a real, working store instead of leaving it nil. It dispatches on
+// distinctive substrings of the (fixed, storage-package-authored) SQL text
+// rather than parsing SQL, since every query it needs to serve is one of a
+// small, known set of templates.
+type mockExecutor struct {
+	mu sync.Mutex
+
+	tasks      map[int64]*mockTaskRow
+	nextTaskID int64
+
+	subtasks      map[int64]*mockSubtaskRow
+	nextSubtaskID int64
+
+	triggers      map[int64]*mockTriggerRow
+	nextTriggerID int64
+
+	events []*mockEventRow
+
+	lastInsertID int64
+}
+
+func newMockExecutor() *mockExecutor {
+	return &mockExecutor{
+		tasks:    make(map[int64]*mockTaskRow),
+		subtasks: make(map[int64]*mockSubtaskRow),
+		triggers: make(map[int64]*mockTriggerRow),
+	}
+}
+
+type mockTaskRow struct {
+	id, priority, concurrency, maxNodeCount int64
+	key, targetScope                        string
+	typ                                     proto.TaskType
+	state                                   proto.TaskState
+	step                                    proto.Step
+	createTime                              time.Time
+	pausedReason                            proto.PausedReason
+	meta, modifyParams                      []byte
+}
+
+type mockSubtaskRow struct {
+	id, taskID  int64
+	step        proto.Step
+	typ         proto.TaskType
+	concurrency int
+	execID      string
+	meta        []byte
+	state       proto.TaskState
+}
+
+type mockTriggerRow struct {
+	id, lastTaskID int64
+	keyPrefix      string
+	taskType       proto.TaskType
+	cronExpr       string
+	concurrency    int
+	meta           []byte
+	nextFireAt     time.Time
+	enabled        bool
+	onOverlap      proto.OverlapPolicy
+}
+
+type mockEventRow struct {
+	taskID, seq        int64
+	kind               proto.EventKind
+	oldValue, newValue string
+	createTime         time.Time
+}
+
+func (m *mockExecutor) ExecuteSQL(_ context.Context, sql string, args ...any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case strings.Contains(sql, "insert into mysql.tidb_global_task"):
+		m.nextTaskID++
+		id := m.nextTaskID
+		m.tasks[id] = &mockTaskRow{
+			id: id, key: args[0].(string), typ: args[1].(proto.TaskType),
+			state: args[2].(proto.TaskState), step: args[3].(proto.Step),
+			priority: int64(args[4].(int)), concurrency: int64(args[5].(int)),
+			maxNodeCount: int64(args[6].(int)), targetScope: args[7].(string),
+			meta: asBytes(args[8]), createTime: args[9].(time.Time),
+		}
+		m.lastInsertID = id
+		return nil
+
+	case strings.Contains(sql, "insert into mysql.tidb_background_subtask"):
+		m.nextSubtaskID++
+		id := m.nextSubtaskID
+		m.subtasks[id] = &mockSubtaskRow{
+			id: id, taskID: args[0].(int64), step: args[1].(proto.Step),
+			typ: args[2].(proto.TaskType), concurrency: args[3].(int),
+			execID: args[4].(string), meta: asBytes(args[5]), state: args[6].(proto.TaskState),
+		}
+		return nil
+
+	case strings.Contains(sql, "insert into mysql.tidb_dxf_trigger"):
+		m.nextTriggerID++
+		id := m.nextTriggerID
+		m.triggers[id] = &mockTriggerRow{
+			id: id, keyPrefix: args[0].(string), taskType: args[1].(proto.TaskType),
+			cronExpr: args[2].(string), concurrency: args[3].(int), meta: asBytes(args[4]),
+			nextFireAt: args[5].(time.Time), enabled: args[6].(bool), onOverlap: args[7].(proto.OverlapPolicy),
+		}
+		m.lastInsertID = id
+		return nil
+
+	case strings.Contains(sql, "insert into mysql.tidb_dxf_task_event"):
+		taskID := args[0].(int64)
+		var seq int64
+		for _, ev := range m.events {
+			if ev.taskID == taskID && ev.seq > seq {
+				seq = ev.seq
+			}
+		}
+		m.events = append(m.events, &mockEventRow{
+			taskID: taskID, seq: seq + 1, kind: args[1].(proto.EventKind),
+			oldValue: args[2].(string), newValue: args[3].(string), createTime: args[4].(time.Time),
+		})
+		return nil
+
+	case strings.Contains(sql, "update mysql.tidb_global_task"):
+		return m.updateTask(sql, args)
+
+	case strings.Contains(sql, "update mysql.tidb_background_subtask"):
+		state, id := args[0].(proto.TaskState), args[1].(int64)
+		if row, ok := m.subtasks[id]; ok {
+			row.state = state
+		}
+		return nil
+
+	case strings.Contains(sql, "delete from mysql.tidb_dxf_trigger"):
+		delete(m.triggers, args[0].(int64))
+		return nil
+
+	case strings.Contains(sql, "update mysql.tidb_dxf_trigger"):
+		return m.updateTrigger(sql, args)
+	}
+	return fmt.Errorf("mockExecutor: unrecognized SQL: %s", sql)
+}
+
+func (m *mockExecutor) updateTask(sql string, args []any) error {
+	switch {
+	case strings.Contains(sql, "modify_params = null"):
+		row, ok := m.taskByID(args[5].(int64))
+		if !ok || row.state != args[6].(proto.TaskState) {
+			return nil
+		}
+		row.state = args[0].(proto.TaskState)
+		row.priority = int64(args[1].(int))
+		row.concurrency = int64(args[2].(int))
+		row.maxNodeCount = int64(args[3].(int))
+		row.targetScope = args[4].(string)
+		row.modifyParams = nil
+	case strings.Contains(sql, "modify_params = %?"):
+		row, ok := m.taskByID(args[2].(int64))
+		if !ok || row.state != args[3].(proto.TaskState) {
+			return nil
+		}
+		row.state = args[0].(proto.TaskState)
+		row.modifyParams = asBytes(args[1])
+	case strings.Contains(sql, "not in ("):
+		row, ok := m.taskByKey(args[1].(string))
+		if !ok {
+			return nil
+		}
+		for _, terminal := range args[2:] {
+			if row.state == terminal.(proto.TaskState) {
+				return nil
+			}
+		}
+		row.state = args[0].(proto.TaskState)
+	case strings.Contains(sql, "where id = %?"):
+		row, ok := m.taskByID(args[2].(int64))
+		if !ok {
+			return nil
+		}
+		row.state = args[0].(proto.TaskState)
+		row.step = args[1].(proto.Step)
+	case strings.Contains(sql, " in (%?, %?)"):
+		// PauseTask: set state = %?, paused_reason = %? where task_key = %? and state in (%?, %?)
+		row, ok := m.taskByKey(args[2].(string))
+		if !ok {
+			return nil
+		}
+		if row.state == args[3].(proto.TaskState) || row.state == args[4].(proto.TaskState) {
+			row.state = args[0].(proto.TaskState)
+			row.pausedReason = args[1].(proto.PausedReason)
+		}
+	default:
+		// ResumeTask: set state = %?, paused_reason = %? where task_key = %? and state = %?
+		row, ok := m.taskByKey(args[2].(string))
+		if !ok || row.state != args[3].(proto.TaskState) {
+			return nil
+		}
+		row.state = args[0].(proto.TaskState)
+		row.pausedReason = args[1].(proto.PausedReason)
+	}
+	return nil
+}
+
+func (m *mockExecutor) updateTrigger(sql string, args []any) error {
+	if strings.Contains(sql, "key_prefix = %?") {
+		row, ok := m.triggers[args[9].(int64)]
+		if !ok {
+			return nil
+		}
+		row.keyPrefix = args[0].(string)
+		row.taskType = args[1].(proto.TaskType)
+		row.cronExpr = args[2].(string)
+		row.concurrency = args[3].(int)
+		row.meta = asBytes(args[4])
+		row.nextFireAt = args[5].(time.Time)
+		row.lastTaskID = args[6].(int64)
+		row.enabled = args[7].(bool)
+		row.onOverlap = args[8].(proto.OverlapPolicy)
+		return nil
+	}
+	// ModifyTrigger: set cron_expr, concurrency, meta, enabled where id = %?
+	row, ok := m.triggers[args[4].(int64)]
+	if !ok {
+		return nil
+	}
+	row.cronExpr = args[0].(string)
+	row.concurrency = args[1].(int)
+	row.meta = asBytes(args[2])
+	row.enabled = args[3].(bool)
+	return nil
+}
+
+func (m *mockExecutor) taskByID(id int64) (*mockTaskRow, bool) {
+	row, ok := m.tasks[id]
+	return row, ok
+}
+
+func (m *mockExecutor) taskByKey(key string) (*mockTaskRow, bool) {
+	for _, row := range m.tasks {
+		if row.key == key {
+			return row, true
+		}
+	}
+	return nil, false
+}
+
+func (m *mockExecutor) QueryRow(_ context.Context, sql string, args ...any) ([]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case strings.Contains(sql, "select last_insert_id()"):
+		return []any{m.lastInsertID}, nil
+
+	case strings.Contains(sql, "select seq from mysql.tidb_dxf_task_event"):
+		taskID := args[0].(int64)
+		var seq int64
+		for _, ev := range m.events {
+			if ev.taskID == taskID && ev.seq > seq {
+				seq = ev.seq
+			}
+		}
+		return []any{seq}, nil
+
+	case strings.Contains(sql, "select id, task_key"):
+		var row *mockTaskRow
+		var ok bool
+		if strings.Contains(sql, "where task_key = %?") {
+			row, ok = m.taskByKey(args[0].(string))
+		} else {
+			row, ok = m.taskByID(args[0].(int64))
+		}
+		if !ok {
+			return nil, fmt.Errorf("mockExecutor: task not found")
+		}
+		return taskRowToColumns(row, strings.Contains(sql, "paused_reason, meta")), nil
+	}
+	return nil, fmt.Errorf("mockExecutor: unrecognized SQL: %s", sql)
+}
+
+func (m *mockExecutor) QueryRows(_ context.Context, sql string, args ...any) ([][]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case strings.Contains(sql, "select id, task_key") && strings.Contains(sql, "order by id asc"):
+		state := args[0].(proto.TaskState)
+		var rows [][]any
+		for id := int64(1); id <= m.nextTaskID; id++ {
+			if row, ok := m.tasks[id]; ok && row.state == state {
+				rows = append(rows, taskRowToColumns(row, false))
+			}
+		}
+		return rows, nil
+
+	case strings.Contains(sql, "select id, task_id, step, type, concurrency, exec_id, meta, state"):
+		return m.queryRowsSubtasks(sql, args)
+
+	case strings.Contains(sql, "select id, key_prefix"):
+		return m.queryRowsTriggers(sql, args)
+
+	case strings.Contains(sql, "select task_id, seq, kind"):
+		taskID := args[0].(int64)
+		var rows [][]any
+		for _, ev := range sortedEvents(m.events, taskID) {
+			rows = append(rows, []any{ev.taskID, ev.seq, ev.kind, ev.oldValue, ev.newValue, ev.createTime})
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("mockExecutor: unrecognized SQL: %s", sql)
+}
+
+func (m *mockExecutor) queryRowsSubtasks(sql string, args []any) ([][]any, error) {
+	var rows [][]any
+	switch {
+	case strings.Contains(sql, "exec_id = %? and state = %?"):
+		execID, state := args[0].(string), args[1].(proto.TaskState)
+		for id := int64(1); id <= m.nextSubtaskID; id++ {
+			if row, ok := m.subtasks[id]; ok && row.execID == execID && row.state == state {
+				rows = append(rows, subtaskRowToColumns(row))
+			}
+		}
+	default: // GetSubtasks / GetSubtasksWithHistory: task_id = %? and step = %? (mock keeps one table)
+		taskID, step := args[0].(int64), args[1].(proto.Step)
+		for id := int64(1); id <= m.nextSubtaskID; id++ {
+			if row, ok := m.subtasks[id]; ok && row.taskID == taskID && row.step == step {
+				rows = append(rows, subtaskRowToColumns(row))
+			}
+		}
+	}
+	return rows, nil
+}
+
+func (m *mockExecutor) queryRowsTriggers(sql string, args []any) ([][]any, error) {
+	var rows [][]any
+	dueOnly := strings.Contains(sql, "for update skip locked")
+	for id := int64(1); id <= m.nextTriggerID; id++ {
+		row, ok := m.triggers[id]
+		if !ok {
+			continue
+		}
+		if dueOnly {
+			now := args[0].(int64)
+			if !row.enabled || row.nextFireAt.Unix() > now {
+				continue
+			}
+		}
+		rows = append(rows, triggerRowToColumns(row))
+	}
+	return rows, nil
+}
+
+func sortedEvents(events []*mockEventRow, taskID int64) []*mockEventRow {
+	var out []*mockEventRow
+	for _, ev := range events {
+		if ev.taskID == taskID {
+			out = append(out, ev)
+		}
+	}
+	for i := 1; i < len(out); i++ {
+		for j := i; j > 0 && out[j-1].seq > out[j].seq; j-- {
+			out[j-1], out[j] = out[j], out[j-1]
+		}
+	}
+	return out
+}
+
+func taskRowToColumns(row *mockTaskRow, withMeta bool) []any {
+	cols := []any{
+		row.id, row.key, row.typ, row.state, row.step,
+		int(row.priority), int(row.concurrency), int(row.maxNodeCount), row.targetScope, row.createTime,
+		row.pausedReason,
+	}
+	if withMeta {
+		cols = append(cols, []byte(row.meta))
+	}
+	return cols
+}
+
+func subtaskRowToColumns(row *mockSubtaskRow) []any {
+	return []any{row.id, row.taskID, row.step, row.typ, row.concurrency, row.execID, row.meta, row.state}
+}
+
+func triggerRowToColumns(row *mockTriggerRow) []any {
+	return []any{
+		row.id, row.keyPrefix, row.taskType, row.cronExpr, row.concurrency, row.meta,
+		row.nextFireAt, row.lastTaskID, row.enabled, row.onOverlap,
+	}
+}
+
+func asBytes(v any) []byte {
+	if v == nil {
+		return nil
+	}
+	b, _ := v.([]byte)
+	return b
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"github.com/pingcap/tidb/pkg/disttask/framework/proto"
+	"go.uber.org/mock/gomock"
+)
+
+// StepInfo describes, for a mock task type, how many subtasks a given step
+// should be split into.
+type StepInfo struct {
+	Step       proto.Step
+	SubtaskCnt int
+}
+
+// SchedulerInfo configures a mock task type: the steps it reports and
+// whether subtask errors should be treated as retryable.
+type SchedulerInfo struct {
+	AllErrorRetryable bool
+	StepInfos         []StepInfo
+}
+
+// GetMockSchedulerExt returns info itself as the mock scheduler extension.
+// ctrl is not used; no expectations are recorded against it.
+func GetMockSchedulerExt(ctrl *gomock.Controller, info SchedulerInfo) any {
+	return info
+}
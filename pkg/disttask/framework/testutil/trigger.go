@@ -0,0 +1,32 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/disttask/framework/scheduler"
+	"github.com/stretchr/testify/require"
+)
+
+// RunTriggerTick drives a single scheduler.TriggerManager.Tick against c's
+// TaskManager and mock Clock, so tests can fast-forward trigger schedules
+// deterministically instead of sleeping real time.
+func RunTriggerTick(t *testing.T, c *TestDXFContext) {
+	t.Helper()
+	mgr := scheduler.NewTriggerManager(c.TaskMgr)
+	mgr.SetClock(c.Clock.Now)
+	require.NoError(t, mgr.Tick(c.Ctx))
+}